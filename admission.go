@@ -0,0 +1,19 @@
+package godave
+
+// AdmissionPolicy decides whether a user may be part of the MLS group of a Session.
+// Users that are not admitted are never recognized by the Session, so it refuses to share keys with them.
+// It is called from the goroutine delivering the voice gateway events and should not block.
+type AdmissionPolicy func(userID UserID) bool
+
+// AllowUserIDs returns an AdmissionPolicy only admitting the given users.
+func AllowUserIDs(userIDs ...UserID) AdmissionPolicy {
+	allowed := make(map[UserID]struct{}, len(userIDs))
+	for _, userID := range userIDs {
+		allowed[userID] = struct{}{}
+	}
+
+	return func(userID UserID) bool {
+		_, ok := allowed[userID]
+		return ok
+	}
+}
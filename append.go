@@ -0,0 +1,48 @@
+package godave
+
+import (
+	"slices"
+)
+
+// AppendSession is implemented by a Session able to append encrypted and decrypted frames to a buffer,
+// growing it as needed instead of requiring callers to size it with MaxEncryptedFrameSize or MaxDecryptedFrameSize.
+// Use EncryptAppend and DecryptAppend to fall back to Session.Encrypt and Session.Decrypt for other sessions.
+type AppendSession interface {
+	// EncryptAppend encrypts frame and appends the encrypted frame to dst.
+	// It returns dst unchanged along with the error if the frame could not be encrypted.
+	EncryptAppend(dst []byte, ssrc uint32, frame []byte) ([]byte, error)
+
+	// DecryptAppend decrypts frame sent by the given user and appends the decrypted frame to dst.
+	// It returns dst unchanged along with the error if the frame could not be decrypted.
+	DecryptAppend(dst []byte, userID UserID, frame []byte) ([]byte, error)
+}
+
+// EncryptAppend encrypts frame with the session and appends the encrypted frame to dst, growing dst as needed.
+// It uses AppendSession.EncryptAppend if the session implements it.
+func EncryptAppend(session Session, dst []byte, ssrc uint32, frame []byte) ([]byte, error) {
+	if appendSession, ok := session.(AppendSession); ok {
+		return appendSession.EncryptAppend(dst, ssrc, frame)
+	}
+
+	dst = slices.Grow(dst, session.MaxEncryptedFrameSize(len(frame)))
+	n, err := session.Encrypt(ssrc, frame, dst[len(dst):cap(dst)])
+	if err != nil {
+		return dst, err
+	}
+	return dst[:len(dst)+n], nil
+}
+
+// DecryptAppend decrypts frame with the session and appends the decrypted frame to dst, growing dst as needed.
+// It uses AppendSession.DecryptAppend if the session implements it.
+func DecryptAppend(session Session, dst []byte, userID UserID, frame []byte) ([]byte, error) {
+	if appendSession, ok := session.(AppendSession); ok {
+		return appendSession.DecryptAppend(dst, userID, frame)
+	}
+
+	dst = slices.Grow(dst, session.MaxDecryptedFrameSize(userID, len(frame)))
+	n, err := session.Decrypt(userID, frame, dst[len(dst):cap(dst)])
+	if err != nil {
+		return dst, err
+	}
+	return dst[:len(dst)+n], nil
+}
@@ -0,0 +1,55 @@
+package godave
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+// sizedSession only implements Session, so the append helpers fall back to Encrypt and Decrypt.
+type sizedSession struct {
+	Session
+}
+
+func TestEncryptAppendFallback(t *testing.T) {
+	createSession, err := NewNoopSessionCreateFunc(WithNoopWarning(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := sizedSession{Session: createSession(slog.Default(), "", nil)}
+	frame := []byte("frame")
+
+	dst, err := EncryptAppend(session, []byte("prefix"), 0, frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(dst) != "prefixframe" {
+		t.Errorf("expected prefixframe, got %q", dst)
+	}
+
+	dst, err = DecryptAppend(session, dst[:0], "", frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst, frame) {
+		t.Errorf("expected frame, got %q", dst)
+	}
+}
+
+func TestEncryptAppendAllocs(t *testing.T) {
+	createSession, err := NewNoopSessionCreateFunc(WithNoopWarning(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := createSession(slog.Default(), "", nil)
+	frame := make([]byte, 160)
+	dst := make([]byte, 0, len(frame))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dst, _ = EncryptAppend(session, dst[:0], 0, frame)
+		dst, _ = DecryptAppend(session, dst[:0], "", frame)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations per frame, got %v", allocs)
+	}
+}
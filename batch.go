@@ -0,0 +1,68 @@
+package godave
+
+// EncryptFrame is a frame encrypted by EncryptBatch.
+type EncryptFrame struct {
+	// SSRC is the SSRC the frame is sent with.
+	SSRC uint32
+	// Frame is the frame to encrypt.
+	Frame []byte
+	// EncryptedFrame receives the encrypted frame, see Session.Encrypt.
+	EncryptedFrame []byte
+
+	// N is set to the number of bytes written to EncryptedFrame.
+	N int
+	// Err is set to the error encrypting the frame, if any.
+	Err error
+}
+
+// DecryptFrame is a frame decrypted by DecryptBatch.
+type DecryptFrame struct {
+	// UserID is the user who sent the frame.
+	UserID UserID
+	// Frame is the frame to decrypt.
+	Frame []byte
+	// DecryptedFrame receives the decrypted frame, see Session.Decrypt.
+	DecryptedFrame []byte
+
+	// N is set to the number of bytes written to DecryptedFrame.
+	N int
+	// Err is set to the error decrypting the frame, if any.
+	Err error
+}
+
+// BatchSession is implemented by a Session able to encrypt and decrypt many frames in a single call,
+// for example all frames of a 20ms tick across all SSRCs and users.
+// Use EncryptBatch and DecryptBatch to fall back to Session.Encrypt and Session.Decrypt for other sessions.
+type BatchSession interface {
+	// EncryptBatch encrypts all frames and sets their N and Err fields.
+	EncryptBatch(frames []EncryptFrame)
+
+	// DecryptBatch decrypts all frames and sets their N and Err fields.
+	DecryptBatch(frames []DecryptFrame)
+}
+
+// EncryptBatch encrypts all frames with the session and sets their N and Err fields.
+// It uses BatchSession.EncryptBatch if the session implements it and Session.Encrypt for each frame otherwise.
+func EncryptBatch(session Session, frames []EncryptFrame) {
+	if batchSession, ok := session.(BatchSession); ok {
+		batchSession.EncryptBatch(frames)
+		return
+	}
+	for i := range frames {
+		frame := &frames[i]
+		frame.N, frame.Err = session.Encrypt(frame.SSRC, frame.Frame, frame.EncryptedFrame)
+	}
+}
+
+// DecryptBatch decrypts all frames with the session and sets their N and Err fields.
+// It uses BatchSession.DecryptBatch if the session implements it and Session.Decrypt for each frame otherwise.
+func DecryptBatch(session Session, frames []DecryptFrame) {
+	if batchSession, ok := session.(BatchSession); ok {
+		batchSession.DecryptBatch(frames)
+		return
+	}
+	for i := range frames {
+		frame := &frames[i]
+		frame.N, frame.Err = session.Decrypt(frame.UserID, frame.Frame, frame.DecryptedFrame)
+	}
+}
@@ -0,0 +1,301 @@
+// Package benchmarks measures godave.Session implementations.
+//
+// The benchmarks cover Encrypt and Decrypt throughput, latency and allocations with and without an established
+// E2EE epoch, the MLS handshake of groups with N participants and the processing of commits adding and removing
+// a participant. Sessions are driven through the DAVE protocol by an in-memory voice gateway, benchmarks requiring
+// DAVE are skipped for sessions that do not support it, such as the noop session.
+//
+// Run the benchmarks from a test of the implementation:
+//
+//	func BenchmarkSession(b *testing.B) {
+//		benchmarks.Run(b, golibdave.NewSession)
+//	}
+//
+// or from any program with Write. Both produce output in the Go benchmark format, so results of two runs, for example
+// before and after bumping libdave, can be compared with benchstat.
+package benchmarks
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/disgoorg/godave"
+)
+
+// ringSize is the number of frames encrypted ahead of the decrypt benchmarks,
+// decryptors reject frames with a nonce they have already seen.
+const ringSize = 256
+
+// errUnsupported is returned by benchmarks requiring DAVE for sessions that do not support it.
+var errUnsupported = errors.New("session does not support DAVE")
+
+// Benchmark is a single benchmark of a godave.Session implementation.
+type Benchmark struct {
+	// Name is the name of the benchmark, without the Benchmark prefix.
+	Name string
+	// F runs the benchmark. It skips the benchmark if the session does not support what is measured.
+	F func(b *testing.B)
+
+	run func(b *testing.B) error
+}
+
+func newBenchmark(name string, run func(b *testing.B) error) Benchmark {
+	return Benchmark{
+		Name: name,
+		F: func(b *testing.B) {
+			if err := run(b); errors.Is(err, errUnsupported) {
+				b.Skip(err)
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		},
+		run: run,
+	}
+}
+
+// New returns the benchmarks of sessions created by create.
+func New(create godave.SessionCreateFunc, opts ...ConfigOpt) []Benchmark {
+	config := DefaultConfig()
+	config.Apply(opts)
+
+	s := &suite{
+		create: create,
+		config: config,
+	}
+
+	benchmarks := []Benchmark{
+		newBenchmark("Encrypt/passthrough", s.benchmarkEncrypt(false)),
+		newBenchmark("Encrypt/e2ee", s.benchmarkEncrypt(true)),
+		newBenchmark("Decrypt/passthrough", s.benchmarkDecrypt(false)),
+		newBenchmark("Decrypt/e2ee", s.benchmarkDecrypt(true)),
+	}
+	for _, participants := range config.Participants {
+		benchmarks = append(benchmarks, newBenchmark("Handshake/participants="+strconv.Itoa(participants), s.benchmarkHandshake(participants)))
+	}
+	for _, participants := range config.Participants {
+		benchmarks = append(benchmarks, newBenchmark("Commit/participants="+strconv.Itoa(participants), s.benchmarkCommit(participants)))
+	}
+	return benchmarks
+}
+
+// Run runs the benchmarks of sessions created by create as sub-benchmarks of b.
+func Run(b *testing.B, create godave.SessionCreateFunc, opts ...ConfigOpt) {
+	for _, benchmark := range New(create, opts...) {
+		b.Run(benchmark.Name, benchmark.F)
+	}
+}
+
+// Write runs the benchmarks of sessions created by create and writes the results to w in the Go benchmark format
+// understood by benchstat. The name of the implementation is written as the impl configuration key,
+// use benchstat -col impl to compare implementations. Benchmarks the sessions do not support are omitted,
+// failed benchmarks are omitted and their errors returned after all benchmarks ran.
+func Write(w io.Writer, name string, create godave.SessionCreateFunc, opts ...ConfigOpt) error {
+	return write(w, name, New(create, opts...))
+}
+
+func write(w io.Writer, name string, benchmarks []Benchmark) error {
+	if _, err := fmt.Fprintf(w, "goos: %s\ngoarch: %s\npkg: github.com/disgoorg/godave/benchmarks\nimpl: %s\n", runtime.GOOS, runtime.GOARCH, name); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, benchmark := range benchmarks {
+		var err error
+		result := testing.Benchmark(func(b *testing.B) {
+			err = benchmark.run(b)
+		})
+		if errors.Is(err, errUnsupported) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", benchmark.Name, err))
+			continue
+		}
+		if err = writeResult(w, benchmark.Name, result); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeResult writes a result line in the Go benchmark format.
+func writeResult(w io.Writer, name string, result testing.BenchmarkResult) error {
+	suffix := ""
+	if procs := runtime.GOMAXPROCS(0); procs > 1 {
+		suffix = "-" + strconv.Itoa(procs)
+	}
+	_, err := fmt.Fprintf(w, "Benchmark%s%s\t%s\t%s\n", name, suffix, result.String(), result.MemString())
+	return err
+}
+
+type suite struct {
+	create godave.SessionCreateFunc
+	config *Config
+}
+
+// group connects the given number of participants to a new gateway and establishes the MLS group if e2ee is set.
+// The gateway is closed when the benchmark finishes.
+func (s *suite) group(b *testing.B, participants int, e2ee bool) (*gateway, error) {
+	g, err := s.newGroup(participants, e2ee)
+	if err != nil {
+		return nil, err
+	}
+	b.Cleanup(g.close)
+	return g, nil
+}
+
+// newGroup connects the given number of participants to a new gateway and establishes the MLS group if e2ee is set.
+// It returns errUnsupported if e2ee is set and the sessions do not support DAVE.
+func (s *suite) newGroup(participants int, e2ee bool) (*gateway, error) {
+	g, err := newGateway(s.create, s.config.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*participant, participants)
+	for i := range members {
+		members[i] = g.connect()
+	}
+	if !e2ee {
+		return g, nil
+	}
+
+	if members[0].session.MaxSupportedProtocolVersion() < protocolVersion {
+		err = errUnsupported
+	} else {
+		err = g.establish(members[0], members[1:]...)
+	}
+	if err != nil {
+		g.close()
+		return nil, err
+	}
+	return g, nil
+}
+
+func (s *suite) frame() []byte {
+	frame := make([]byte, s.config.FrameSize)
+	_, _ = rand.Read(frame)
+	return frame
+}
+
+func (s *suite) benchmarkEncrypt(e2ee bool) func(b *testing.B) error {
+	return func(b *testing.B) error {
+		g, err := s.group(b, 2, e2ee)
+		if err != nil {
+			return err
+		}
+		session := g.connected[0].session
+		session.AssignSsrcToCodec(1, godave.CodecOpus)
+
+		frame := s.frame()
+		encryptedFrame := make([]byte, session.MaxEncryptedFrameSize(len(frame)))
+
+		b.SetBytes(int64(len(frame)))
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err = session.Encrypt(1, frame, encryptedFrame); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (s *suite) benchmarkDecrypt(e2ee bool) func(b *testing.B) error {
+	return func(b *testing.B) error {
+		g, err := s.group(b, 2, e2ee)
+		if err != nil {
+			return err
+		}
+		sender, receiver := g.connected[0], g.connected[1]
+		sender.session.AssignSsrcToCodec(1, godave.CodecOpus)
+
+		frame := s.frame()
+		frames := make([][]byte, ringSize)
+		encrypt := func() error {
+			for i := range frames {
+				encryptedFrame := make([]byte, sender.session.MaxEncryptedFrameSize(len(frame)))
+				n, err := sender.session.Encrypt(1, frame, encryptedFrame)
+				if err != nil {
+					return err
+				}
+				frames[i] = encryptedFrame[:n]
+			}
+			return nil
+		}
+		if err = encrypt(); err != nil {
+			return err
+		}
+		decryptedFrame := make([]byte, receiver.session.MaxDecryptedFrameSize(sender.userID, len(frames[0])))
+
+		b.SetBytes(int64(len(frame)))
+		b.ReportAllocs()
+		i := 0
+		for b.Loop() {
+			if i == len(frames) {
+				b.StopTimer()
+				if err = encrypt(); err != nil {
+					return err
+				}
+				i = 0
+				b.StartTimer()
+			}
+			if _, err = receiver.session.Decrypt(sender.userID, frames[i], decryptedFrame); err != nil {
+				return err
+			}
+			i++
+		}
+		return nil
+	}
+}
+
+func (s *suite) benchmarkHandshake(participants int) func(b *testing.B) error {
+	return func(b *testing.B) error {
+		// check for DAVE support once instead of failing every iteration
+		if _, err := s.group(b, participants, true); err != nil {
+			return err
+		}
+
+		b.ReportAllocs()
+		for b.Loop() {
+			g, err := s.newGroup(participants, true)
+			if err != nil {
+				return err
+			}
+			b.StopTimer()
+			g.close()
+			b.StartTimer()
+		}
+		return nil
+	}
+}
+
+func (s *suite) benchmarkCommit(participants int) func(b *testing.B) error {
+	return func(b *testing.B) error {
+		g, err := s.group(b, participants, true)
+		if err != nil {
+			return err
+		}
+
+		b.ReportAllocs()
+		for b.Loop() {
+			b.StopTimer()
+			joiner := g.connect()
+			b.StartTimer()
+
+			if err = g.add(joiner); err != nil {
+				return err
+			}
+			if err = g.remove(joiner); err != nil {
+				return err
+			}
+		}
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(2*b.N), "ns/commit")
+		return nil
+	}
+}
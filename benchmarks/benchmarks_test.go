@@ -0,0 +1,88 @@
+package benchmarks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/godave"
+)
+
+func TestWriteResult(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeResult(&buf, "Encrypt/passthrough", testing.BenchmarkResult{
+		N:     100,
+		T:     time.Millisecond,
+		Bytes: 160,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	line := regexp.MustCompile(`^BenchmarkEncrypt/passthrough(-\d+)?\t\s*100\t\s*10000 ns/op\t.* MB/s\t\s*0 B/op\t\s*0 allocs/op\n$`)
+	if !line.MatchString(buf.String()) {
+		t.Errorf("expected result line, got %q", buf.String())
+	}
+}
+
+func TestWriteUnsupported(t *testing.T) {
+	// the noop session does not support DAVE, so the e2ee benchmarks are skipped
+	createSession, err := godave.NewNoopSessionCreateFunc(godave.WithNoopWarning(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var benchmarks []Benchmark
+	for _, benchmark := range New(createSession) {
+		if !strings.HasSuffix(benchmark.Name, "/passthrough") {
+			benchmarks = append(benchmarks, benchmark)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, "noop", benchmarks); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+	if !regexp.MustCompile(`(?m)^impl: noop$`).MatchString(output) {
+		t.Errorf("expected impl configuration line, got:\n%s", output)
+	}
+	if strings.Contains(output, "Benchmark") {
+		t.Errorf("expected DAVE benchmarks to be skipped, got:\n%s", output)
+	}
+}
+
+func TestSplitCommitWelcome(t *testing.T) {
+	commit := binary.BigEndian.AppendUint16(nil, mlsVersion10)
+	commit = binary.BigEndian.AppendUint16(commit, wireFormatPublicMessage)
+	commit = appendVector(commit, []byte{0, 0, 0, 0, 0, 0, 0, 1})
+	commit = binary.BigEndian.AppendUint64(commit, 3)
+	commit = append(commit, senderTypeMember)
+	commit = binary.BigEndian.AppendUint32(commit, 0)
+	commit = appendVector(commit, nil)
+	commit = append(commit, contentTypeCommit)
+	commit = appendVector(commit, bytes.Repeat([]byte{1}, 100))
+	commit = append(commit, 0) // no path
+	commit = appendVector(commit, bytes.Repeat([]byte{2}, 72))
+	commit = appendVector(commit, bytes.Repeat([]byte{3}, 32))
+	commit = appendVector(commit, bytes.Repeat([]byte{4}, 32))
+	welcome := []byte("welcome")
+
+	gotCommit, gotWelcome, err := splitCommitWelcome(append(commit, welcome...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotCommit, commit) {
+		t.Errorf("expected commit of %d bytes, got %d", len(commit), len(gotCommit))
+	}
+	if !bytes.Equal(gotWelcome, welcome) {
+		t.Errorf("expected welcome %q, got %q", welcome, gotWelcome)
+	}
+
+	if _, _, err = splitCommitWelcome(commit[:len(commit)-1]); err == nil {
+		t.Error("expected truncated commit to fail")
+	}
+}
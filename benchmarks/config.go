@@ -0,0 +1,55 @@
+package benchmarks
+
+import (
+	"log/slog"
+)
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		Logger:       slog.New(slog.DiscardHandler),
+		FrameSize:    160,
+		Participants: []int{2, 8, 32},
+	}
+}
+
+// Config is the configuration of the benchmarks.
+type Config struct {
+	// Logger is passed to the sessions under benchmark.
+	Logger *slog.Logger
+	// FrameSize is the size of the frames encrypted and decrypted, the default is the size of a typical 20ms Opus frame.
+	FrameSize int
+	// Participants are the group sizes the handshake and commit benchmarks run with.
+	Participants []int
+}
+
+// ConfigOpt is a type alias for a function that takes a Config and is used to configure the benchmarks.
+type ConfigOpt func(config *Config)
+
+// Apply applies the given ConfigOpt(s) to the Config.
+func (c *Config) Apply(opts []ConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithLogger sets the logger passed to the sessions under benchmark.
+func WithLogger(logger *slog.Logger) ConfigOpt {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithFrameSize sets the size of the frames encrypted and decrypted.
+func WithFrameSize(frameSize int) ConfigOpt {
+	return func(config *Config) {
+		config.FrameSize = frameSize
+	}
+}
+
+// WithParticipants sets the group sizes the handshake and commit benchmarks run with.
+func WithParticipants(participants ...int) ConfigOpt {
+	return func(config *Config) {
+		config.Participants = participants
+	}
+}
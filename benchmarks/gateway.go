@@ -0,0 +1,227 @@
+package benchmarks
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/disgoorg/godave"
+)
+
+// protocolVersion is the DAVE protocol version negotiated by the gateway.
+const protocolVersion = 1
+
+var (
+	errNoKeyPackage      = errors.New("session did not send a key package")
+	errNoCommit          = errors.New("session did not commit the proposals")
+	errNoWelcome         = errors.New("commit did not include a welcome")
+	errInvalidTransition = errors.New("session rejected the commit or welcome")
+	errNotReady          = errors.New("session is not ready after the transition")
+)
+
+var _ godave.Callbacks = (*participant)(nil)
+
+// participant is a session connected to the gateway.
+type participant struct {
+	userID        godave.UserID
+	session       godave.Session
+	keyPackage    []byte
+	commitWelcome []byte
+	invalid       bool
+}
+
+func (p *participant) SendMLSKeyPackage(keyPackage []byte) error {
+	p.keyPackage = slices.Clone(keyPackage)
+	return nil
+}
+
+func (p *participant) SendMLSCommitWelcome(commitWelcome []byte) error {
+	p.commitWelcome = slices.Clone(commitWelcome)
+	return nil
+}
+
+func (p *participant) SendReadyForTransition(_ uint16) error {
+	return nil
+}
+
+func (p *participant) SendInvalidCommitWelcome(_ uint16) error {
+	p.invalid = true
+	return nil
+}
+
+// gateway is an in-memory voice gateway driving sessions through the DAVE protocol.
+// It delivers events synchronously and always lets the first member of the group commit.
+type gateway struct {
+	create    godave.SessionCreateFunc
+	logger    *slog.Logger
+	channelID godave.ChannelID
+	signer    *externalSigner
+
+	// members holds the members of the MLS group by leaf index, nil for blank leaves
+	members      []*participant
+	connected    []*participant
+	epoch        uint64
+	transitionID uint16
+	nextUserID   uint64
+}
+
+func newGateway(create godave.SessionCreateFunc, logger *slog.Logger) (*gateway, error) {
+	signer, err := newExternalSigner()
+	if err != nil {
+		return nil, err
+	}
+	return &gateway{
+		create:     create,
+		logger:     logger,
+		channelID:  1,
+		signer:     signer,
+		nextUserID: 1000,
+	}, nil
+}
+
+// groupID returns the MLS group ID sessions derive from the channel ID.
+func (g *gateway) groupID() []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(g.channelID))
+}
+
+// connect creates a session for a new user joining the voice channel and negotiates DAVE with it.
+func (g *gateway) connect() *participant {
+	p := &participant{
+		userID: godave.UserID(strconv.FormatUint(g.nextUserID, 10)),
+	}
+	g.nextUserID++
+
+	p.session = g.create(g.logger, p.userID, p)
+	p.session.SetChannelID(g.channelID)
+	for _, other := range g.connected {
+		other.session.AddUser(p.userID)
+		p.session.AddUser(other.userID)
+	}
+	g.connected = append(g.connected, p)
+
+	p.session.OnSelectProtocolAck(protocolVersion)
+	p.session.OnDaveMLSExternalSenderPackage(g.signer.externalSenderPackage())
+	return p
+}
+
+// disconnect removes the user from the voice channel and closes its session.
+func (g *gateway) disconnect(p *participant) {
+	g.connected = slices.DeleteFunc(g.connected, func(other *participant) bool {
+		return other == p
+	})
+	for _, other := range g.connected {
+		other.session.RemoveUser(p.userID)
+	}
+	_ = p.session.Close()
+}
+
+// close closes all connected sessions.
+func (g *gateway) close() {
+	for _, p := range g.connected {
+		_ = p.session.Close()
+	}
+	g.connected = nil
+	g.members = nil
+}
+
+// establish creates the MLS group with the founder and adds the joiners to it.
+func (g *gateway) establish(founder *participant, joiners ...*participant) error {
+	g.members = []*participant{founder}
+	g.epoch = 0
+	return g.add(joiners...)
+}
+
+// add proposes adding the joiners to the group and executes the resulting commit.
+func (g *gateway) add(joiners ...*participant) error {
+	proposals := make([][]byte, len(joiners))
+	for i, joiner := range joiners {
+		if joiner.keyPackage == nil {
+			return fmt.Errorf("user %s: %w", joiner.userID, errNoKeyPackage)
+		}
+		proposals[i] = addProposal(joiner.keyPackage)
+	}
+	return g.commit(proposals, joiners, nil)
+}
+
+// remove proposes removing the member from the group, executes the resulting commit and disconnects the member.
+func (g *gateway) remove(p *participant) error {
+	leafIndex := slices.Index(g.members, p)
+	if leafIndex < 0 {
+		return fmt.Errorf("user %s is not a member of the group", p.userID)
+	}
+	g.disconnect(p)
+	return g.commit([][]byte{removeProposal(uint32(leafIndex))}, nil, p)
+}
+
+func (g *gateway) commit(proposals [][]byte, joiners []*participant, removed *participant) error {
+	committer := g.members[0]
+	if committer == removed {
+		return fmt.Errorf("user %s can not remove itself", removed.userID)
+	}
+
+	payload, err := g.signer.proposals(g.groupID(), g.epoch, proposals...)
+	if err != nil {
+		return err
+	}
+	committer.commitWelcome = nil
+	committer.session.OnDaveMLSProposals(payload)
+	if committer.commitWelcome == nil {
+		return fmt.Errorf("user %s: %w", committer.userID, errNoCommit)
+	}
+	commit, welcome, err := splitCommitWelcome(committer.commitWelcome)
+	if err != nil {
+		return err
+	}
+	if len(joiners) > 0 && len(welcome) == 0 {
+		return errNoWelcome
+	}
+
+	g.transitionID++
+	g.epoch++
+	if removed != nil {
+		g.members[slices.Index(g.members, removed)] = nil
+	}
+
+	var participants []*participant
+	for _, member := range g.members {
+		if member == nil {
+			continue
+		}
+		member.session.OnDaveMLSPrepareCommitTransition(g.transitionID, commit)
+		participants = append(participants, member)
+	}
+	for _, joiner := range joiners {
+		joiner.session.OnDaveMLSWelcome(g.transitionID, welcome)
+		participants = append(participants, joiner)
+		g.join(joiner)
+	}
+	// trailing blank leaves are truncated from the tree
+	for len(g.members) > 0 && g.members[len(g.members)-1] == nil {
+		g.members = g.members[:len(g.members)-1]
+	}
+
+	for _, p := range participants {
+		p.session.OnDaveExecuteTransition(g.transitionID)
+	}
+	for _, p := range participants {
+		if p.invalid {
+			return fmt.Errorf("user %s: %w", p.userID, errInvalidTransition)
+		}
+		if !p.session.Ready() {
+			return fmt.Errorf("user %s: %w", p.userID, errNotReady)
+		}
+	}
+	return nil
+}
+
+// join places the joiner in the leftmost blank leaf like MLS does.
+func (g *gateway) join(joiner *participant) {
+	if i := slices.Index(g.members, nil); i >= 0 {
+		g.members[i] = joiner
+		return
+	}
+	g.members = append(g.members, joiner)
+}
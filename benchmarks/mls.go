@@ -0,0 +1,278 @@
+package benchmarks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// The voice gateway side of the MLS messages exchanged with DAVE sessions, see RFC 9420.
+// Only the parts needed to drive sessions through handshakes and commits are implemented.
+
+const (
+	mlsVersion10 uint16 = 1
+
+	wireFormatPublicMessage uint16 = 1
+
+	senderTypeMember            uint8 = 1
+	senderTypeExternal          uint8 = 2
+	senderTypeNewMemberProposal uint8 = 3
+	senderTypeNewMemberCommit   uint8 = 4
+
+	contentTypeApplication uint8 = 1
+	contentTypeProposal    uint8 = 2
+	contentTypeCommit      uint8 = 3
+
+	proposalTypeAdd    uint16 = 1
+	proposalTypeRemove uint16 = 3
+
+	credentialTypeBasic uint16 = 1
+	credentialTypeX509  uint16 = 2
+
+	leafNodeSourceKeyPackage uint8 = 1
+	leafNodeSourceUpdate     uint8 = 2
+	leafNodeSourceCommit     uint8 = 3
+
+	proposalsOperationAppend uint8 = 0
+)
+
+var errMalformedMessage = errors.New("malformed MLS message")
+
+// appendVarint appends a variable-length integer as defined in RFC 9000 section 16 and used by MLS vector lengths.
+func appendVarint(b []byte, v int) []byte {
+	switch {
+	case v < 1<<6:
+		return append(b, byte(v))
+	case v < 1<<14:
+		return binary.BigEndian.AppendUint16(b, uint16(v)|0x4000)
+	default:
+		return binary.BigEndian.AppendUint32(b, uint32(v)|0x80000000)
+	}
+}
+
+// appendVector appends data prefixed with its variable-length size.
+func appendVector(b []byte, data []byte) []byte {
+	return append(appendVarint(b, len(data)), data...)
+}
+
+// externalSigner signs proposals as the external sender of the MLS group, like the voice gateway does.
+type externalSigner struct {
+	key       *ecdsa.PrivateKey
+	publicKey []byte
+}
+
+func newExternalSigner() (*externalSigner, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := key.PublicKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	return &externalSigner{
+		key:       key,
+		publicKey: publicKey.Bytes(),
+	}, nil
+}
+
+// externalSenderPackage returns the ExternalSender struct sent to sessions with the dave_mls_external_sender_package opcode.
+func (s *externalSigner) externalSenderPackage() []byte {
+	b := appendVector(nil, s.publicKey)
+	b = binary.BigEndian.AppendUint16(b, credentialTypeBasic)
+	return appendVector(b, make([]byte, 8))
+}
+
+// proposals returns the payload of the dave_mls_proposals opcode appending the given proposals.
+func (s *externalSigner) proposals(groupID []byte, epoch uint64, proposals ...[]byte) ([]byte, error) {
+	var messages []byte
+	for _, proposal := range proposals {
+		message, err := s.proposalMessage(groupID, epoch, proposal)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message...)
+	}
+	return appendVector([]byte{proposalsOperationAppend}, messages), nil
+}
+
+// proposalMessage returns a MLSMessage containing a PublicMessage with the proposal sent by the external sender.
+func (s *externalSigner) proposalMessage(groupID []byte, epoch uint64, proposal []byte) ([]byte, error) {
+	// FramedContent
+	content := appendVector(nil, groupID)
+	content = binary.BigEndian.AppendUint64(content, epoch)
+	content = append(content, senderTypeExternal)
+	content = binary.BigEndian.AppendUint32(content, 0)
+	content = appendVector(content, nil)
+	content = append(content, contentTypeProposal)
+	content = append(content, proposal...)
+
+	// FramedContentTBS, external senders do not include the GroupContext
+	tbs := binary.BigEndian.AppendUint16(nil, mlsVersion10)
+	tbs = binary.BigEndian.AppendUint16(tbs, wireFormatPublicMessage)
+	tbs = append(tbs, content...)
+
+	signature, err := s.signWithLabel("FramedContentTBS", tbs)
+	if err != nil {
+		return nil, err
+	}
+
+	message := binary.BigEndian.AppendUint16(nil, mlsVersion10)
+	message = binary.BigEndian.AppendUint16(message, wireFormatPublicMessage)
+	message = append(message, content...)
+	// FramedContentAuthData of a proposal only contains the signature, external senders have no membership tag
+	return appendVector(message, signature), nil
+}
+
+func (s *externalSigner) signWithLabel(label string, content []byte) ([]byte, error) {
+	signContent := appendVector(nil, []byte("MLS 1.0 "+label))
+	signContent = appendVector(signContent, content)
+	digest := sha256.Sum256(signContent)
+	return ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+}
+
+func addProposal(keyPackage []byte) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, proposalTypeAdd), keyPackage...)
+}
+
+func removeProposal(leafIndex uint32) []byte {
+	return binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint16(nil, proposalTypeRemove), leafIndex)
+}
+
+// splitCommitWelcome splits the payload of the dave_mls_commit_welcome opcode into the commit MLSMessage and the optional Welcome.
+func splitCommitWelcome(commitWelcome []byte) ([]byte, []byte, error) {
+	r := &reader{data: commitWelcome}
+	r.skipPublicMessage()
+	if r.err != nil {
+		return nil, nil, fmt.Errorf("failed to read commit: %w", r.err)
+	}
+	return commitWelcome[:r.off], commitWelcome[r.off:], nil
+}
+
+// reader reads the TLS presentation language encoding of MLS structures. The first error is kept in err.
+type reader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data)-r.off < n {
+		r.err = errMalformedMessage
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *reader) uint8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) varint() int {
+	first := r.uint8()
+	length := 1 << (first >> 6)
+	if length == 8 {
+		r.err = errMalformedMessage
+		return 0
+	}
+	v := int(first & 0x3f)
+	for _, b := range r.next(length - 1) {
+		v = v<<8 | int(b)
+	}
+	return v
+}
+
+func (r *reader) vector() []byte {
+	return r.next(r.varint())
+}
+
+func (r *reader) skipPublicMessage() {
+	if r.uint16() != mlsVersion10 || r.uint16() != wireFormatPublicMessage {
+		r.err = errMalformedMessage
+		return
+	}
+
+	// FramedContent
+	r.vector() // group_id
+	r.next(8)  // epoch
+	senderType := r.uint8()
+	switch senderType {
+	case senderTypeMember, senderTypeExternal:
+		r.next(4)
+	case senderTypeNewMemberProposal, senderTypeNewMemberCommit:
+	default:
+		r.err = errMalformedMessage
+		return
+	}
+	r.vector() // authenticated_data
+	contentType := r.uint8()
+	switch contentType {
+	case contentTypeApplication:
+		r.vector()
+	case contentTypeCommit:
+		r.vector() // proposals
+		if r.uint8() == 1 {
+			r.skipLeafNode()
+			r.vector() // nodes
+		}
+	default:
+		// proposals are only sent by the external sender
+		r.err = errMalformedMessage
+		return
+	}
+
+	// FramedContentAuthData
+	r.vector() // signature
+	if contentType == contentTypeCommit {
+		r.vector() // confirmation_tag
+	}
+	if senderType == senderTypeMember {
+		r.vector() // membership_tag
+	}
+}
+
+func (r *reader) skipLeafNode() {
+	r.vector() // encryption_key
+	r.vector() // signature_key
+	switch r.uint16() {
+	case credentialTypeBasic, credentialTypeX509:
+		r.vector() // identity or certificates
+	default:
+		r.err = errMalformedMessage
+		return
+	}
+	for range 5 {
+		r.vector() // capabilities
+	}
+	switch r.uint8() {
+	case leafNodeSourceKeyPackage:
+		r.next(16) // lifetime
+	case leafNodeSourceUpdate:
+	case leafNodeSourceCommit:
+		r.vector() // parent_hash
+	default:
+		r.err = errMalformedMessage
+		return
+	}
+	r.vector() // extensions
+	r.vector() // signature
+}
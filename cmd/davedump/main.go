@@ -0,0 +1,217 @@
+// Command davedump prints the structure of frames encrypted with the DAVE protocol and flags malformed frames.
+//
+// Usage:
+//
+//	davedump [flags] [file ...]
+//
+// Frames are read from the given files, or from standard input without any. In hex format every non-empty line
+// is one frame, whitespace and colons are ignored and lines starting with # are skipped. In binary format every
+// file is one frame. The default auto format uses hex if the input only contains hex digits.
+//
+// davedump exits with status 1 if any frame is malformed. Frames without the magic marker are reported as
+// unencrypted, as they are passed through while no E2EE epoch is established.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/disgoorg/godave/daveframe"
+)
+
+var errMalformedFrames = errors.New("malformed frames found")
+
+func main() {
+	format := flag.String("format", "auto", "input format: auto, hex or binary")
+	jsonOutput := flag.Bool("json", false, "print one JSON object per frame")
+	flag.Parse()
+
+	d := &dumper{
+		format: *format,
+		json:   *jsonOutput,
+		out:    os.Stdout,
+	}
+	if err := d.run(flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+type dumper struct {
+	format    string
+	json      bool
+	out       io.Writer
+	index     int
+	malformed int
+}
+
+func (d *dumper) run(files []string) error {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, file := range files {
+		if err := d.dumpFile(file); err != nil {
+			return err
+		}
+	}
+	if d.malformed > 0 {
+		return fmt.Errorf("%w: %d of %d", errMalformedFrames, d.malformed, d.index)
+	}
+	return nil
+}
+
+func (d *dumper) dumpFile(file string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+		file = "stdin"
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch d.format {
+	case "hex":
+	case "binary":
+		return d.dump(file, data)
+	case "auto":
+		if !isHex(data) {
+			return d.dump(file, data)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", d.format)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		frame, err := hex.DecodeString(strings.NewReplacer(" ", "", "\t", "", ":", "").Replace(text))
+		if err != nil {
+			return fmt.Errorf("%s:%d: invalid hex: %w", file, line, err)
+		}
+		if err = d.dump(fmt.Sprintf("%s:%d", file, line), frame); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// isHex reports whether data only contains hex digits, separators and comments.
+func isHex(data []byte) bool {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if bytes.HasPrefix(line, []byte("#")) {
+			continue
+		}
+		for _, c := range line {
+			if !strings.ContainsRune("0123456789abcdefABCDEF :\t", rune(c)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// frameInfo is the JSON representation of a dumped frame.
+type frameInfo struct {
+	Index     int          `json:"index"`
+	Source    string       `json:"source"`
+	Size      int          `json:"size"`
+	Encrypted bool         `json:"encrypted"`
+	Silence   bool         `json:"silence,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Frame     *parsedFrame `json:"frame,omitempty"`
+}
+
+// parsedFrame is the JSON representation of a valid encrypted frame.
+type parsedFrame struct {
+	PayloadSize          int               `json:"payload_size"`
+	EncryptedSize        int               `json:"encrypted_size"`
+	UnencryptedRanges    []daveframe.Range `json:"unencrypted_ranges"`
+	Tag                  string            `json:"tag"`
+	Nonce                uint32            `json:"nonce"`
+	Generation           uint8             `json:"generation"`
+	SupplementalDataSize int               `json:"supplemental_data_size"`
+}
+
+func (d *dumper) dump(source string, data []byte) error {
+	d.index++
+	info := frameInfo{
+		Index:  d.index,
+		Source: source,
+		Size:   len(data),
+	}
+
+	frame, err := daveframe.Parse(data)
+	switch {
+	case errors.Is(err, daveframe.ErrMissingMagicMarker):
+		info.Silence = daveframe.IsOpusSilence(data)
+	case err != nil:
+		info.Encrypted = true
+		info.Error = err.Error()
+		d.malformed++
+	default:
+		info.Encrypted = true
+		info.Frame = &parsedFrame{
+			PayloadSize:          len(frame.Payload),
+			EncryptedSize:        frame.EncryptedSize(),
+			UnencryptedRanges:    append([]daveframe.Range{}, frame.UnencryptedRanges...),
+			Tag:                  hex.EncodeToString(frame.Tag),
+			Nonce:                frame.Nonce,
+			Generation:           frame.Generation(),
+			SupplementalDataSize: frame.SupplementalDataSize,
+		}
+	}
+
+	if d.json {
+		return json.NewEncoder(d.out).Encode(info)
+	}
+	return d.print(info)
+}
+
+func (d *dumper) print(info frameInfo) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "frame %d (%s): %d bytes\n", info.Index, info.Source, info.Size)
+	switch {
+	case !info.Encrypted && info.Silence:
+		b.WriteString("  unencrypted: opus silence frame\n")
+	case !info.Encrypted:
+		b.WriteString("  unencrypted: no magic marker\n")
+	case info.Error != "":
+		fmt.Fprintf(&b, "  MALFORMED: %s\n", info.Error)
+	default:
+		frame := info.Frame
+		fmt.Fprintf(&b, "  payload:            %d bytes (%d encrypted, %d unencrypted)\n", frame.PayloadSize, frame.EncryptedSize, frame.PayloadSize-frame.EncryptedSize)
+		fmt.Fprintf(&b, "  tag:                %s\n", frame.Tag)
+		fmt.Fprintf(&b, "  nonce:              %d (generation %d)\n", frame.Nonce, frame.Generation)
+		if len(frame.UnencryptedRanges) == 0 {
+			b.WriteString("  unencrypted ranges: none\n")
+		} else {
+			b.WriteString("  unencrypted ranges:")
+			for _, r := range frame.UnencryptedRanges {
+				fmt.Fprintf(&b, " [%d, %d)", r.Offset, r.End())
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  supplemental data:  %d bytes\n", frame.SupplementalDataSize)
+	}
+	_, err := io.WriteString(d.out, b.String())
+	return err
+}
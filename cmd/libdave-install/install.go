@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// platform describes where the files of a libdave release archive are installed on an operating system.
+type platform struct {
+	os          string
+	releaseOS   string
+	releaseArch string
+	// files maps archive paths to install paths relative to the prefix.
+	files map[string]string
+	// library is the install path of the library loaded at runtime relative to the prefix.
+	library string
+	// libraryPathEnv is the environment variable the dynamic loader searches libraries in.
+	libraryPathEnv string
+}
+
+func newPlatform(goos string, goarch string) (*platform, error) {
+	p := &platform{
+		os: goos,
+	}
+	switch goos {
+	case "linux":
+		p.releaseOS = "Linux"
+		p.library = "lib/libdave.so"
+		p.libraryPathEnv = "LD_LIBRARY_PATH"
+	case "darwin":
+		p.releaseOS = "macOS"
+		p.library = "lib/libdave.dylib"
+		p.libraryPathEnv = "DYLD_LIBRARY_PATH"
+	case "windows":
+		p.releaseOS = "Windows"
+		p.library = "bin/libdave.dll"
+		p.libraryPathEnv = "PATH"
+	default:
+		return nil, fmt.Errorf("no prebuilt libdave releases for %s, build libdave from source instead", goos)
+	}
+	switch goarch {
+	case "amd64":
+		p.releaseArch = "X64"
+	case "arm64":
+		p.releaseArch = "ARM64"
+	default:
+		return nil, fmt.Errorf("no prebuilt libdave releases for %s/%s, build libdave from source instead", goos, goarch)
+	}
+
+	p.files = map[string]string{
+		"include/dave/dave.h": "include/dave.h",
+		p.library:             p.library,
+	}
+	if goos == "windows" {
+		p.files["lib/libdave.lib"] = "lib/libdave.lib"
+	}
+	return p, nil
+}
+
+// assetName returns the name of the prebuilt release archive for the platform.
+func (p *platform) assetName(ssl string) string {
+	return fmt.Sprintf("libdave-%s-%s-%s.zip", p.releaseOS, p.releaseArch, ssl)
+}
+
+// unpack installs the files of the release archive at archivePath into prefix.
+func (p *platform) unpack(archivePath string, prefix string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	found := make(map[string]bool, len(p.files))
+	for _, f := range r.File {
+		name := path.Clean(strings.TrimPrefix(f.Name, "./"))
+		dst, ok := p.files[name]
+		if !ok || found[name] {
+			continue
+		}
+		if err = extractFile(f, filepath.Join(prefix, filepath.FromSlash(dst))); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		found[name] = true
+	}
+	for name := range p.files {
+		if !found[name] {
+			return fmt.Errorf("archive does not contain %s", name)
+		}
+	}
+	return nil
+}
+
+// extractFile writes f to dst. The file is written next to dst and renamed,
+// so a library in use by a running process is replaced instead of modified.
+func extractFile(f *zip.File, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, rc); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(0o755); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// pkgConfigDir returns the directory the pkg-config file is written to relative to the prefix.
+func pkgConfigDir() string {
+	return filepath.Join("lib", "pkgconfig")
+}
+
+// writePkgConfig writes the dave.pc file for a libdave installed into prefix and returns its path.
+func (p *platform) writePkgConfig(prefix string, version string) (string, error) {
+	absPrefix, err := filepath.Abs(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	version = strings.TrimSuffix(version, "/cpp")
+	libs := "-L${libdir} -ldave"
+	if p.os != "windows" {
+		libs += " -Wl,-rpath,${libdir}"
+	}
+	// pkg-config on Windows understands forward slashes, backslashes would be treated as escapes.
+	content := fmt.Sprintf(`prefix=%s
+exec_prefix=${prefix}
+libdir=${exec_prefix}/lib
+includedir=${prefix}/include
+
+Name: dave
+Description: Discord Audio & Video End-to-End Encryption (DAVE) Protocol
+Version: %s
+URL: https://github.com/%s
+Libs: %s
+Cflags: -I${includedir} -DLIBDAVE_VERSION=%s
+`, filepath.ToSlash(absPrefix), version, libdaveRepo, libs, version)
+
+	dir := filepath.Join(prefix, pkgConfigDir())
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	pcFile := filepath.Join(dir, "dave.pc")
+	if err = os.WriteFile(pcFile, []byte(content), 0o644); err != nil {
+		return "", err
+	}
+	return pcFile, nil
+}
+
+// env returns the environment variables to export to build and run against a libdave installed into prefix.
+func (p *platform) env(prefix string) []string {
+	absPrefix, err := filepath.Abs(prefix)
+	if err != nil {
+		absPrefix = prefix
+	}
+	library := filepath.Join(absPrefix, filepath.FromSlash(p.library))
+
+	if p.os == "windows" {
+		return []string{
+			fmt.Sprintf(`$env:PKG_CONFIG_PATH = "%s;$env:PKG_CONFIG_PATH"`, filepath.Join(absPrefix, pkgConfigDir())),
+			fmt.Sprintf(`$env:PATH = "%s;$env:PATH"`, filepath.Dir(library)),
+		}
+	}
+	return []string{
+		fmt.Sprintf(`export PKG_CONFIG_PATH="%s:$PKG_CONFIG_PATH"`, filepath.Join(absPrefix, pkgConfigDir())),
+		fmt.Sprintf(`export %s="%s:$%s"`, p.libraryPathEnv, filepath.Dir(library), p.libraryPathEnv),
+		fmt.Sprintf(`export LIBDAVE_PATH="%s"`, library),
+	}
+}
+
+// defaultPrefix returns the prefix the install scripts use.
+func defaultPrefix() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "libdave")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".local"
+	}
+	return filepath.Join(home, ".local")
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyChecksum compares the SHA-256 checksum of an archive to the expected checksum,
+// which may be given in the "sha256:<hex>" format used by GitHub.
+func verifyChecksum(sum string, expected string) error {
+	expected = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(expected), "sha256:"))
+	if sum != expected {
+		return fmt.Errorf("checksum mismatch: archive has SHA-256 %s, expected %s", sum, expected)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeArchive(t *testing.T, files map[string]string) (string, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "libdave.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	return path, hex.EncodeToString(sum[:])
+}
+
+func TestInstallArchive(t *testing.T) {
+	archive, checksum := writeArchive(t, map[string]string{
+		"include/dave/dave.h": "header",
+		"lib/libdave.so":      "library",
+		"README.md":           "readme",
+	})
+	prefix := t.TempDir()
+
+	err := run(options{
+		Version:  "v1.1.0",
+		Prefix:   prefix,
+		Archive:  archive,
+		Checksum: "sha256:" + strings.ToUpper(checksum),
+		SSL:      "boringssl",
+		OS:       "linux",
+		Arch:     "amd64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"include/dave.h": "header",
+		"lib/libdave.so": "library",
+	} {
+		data, err := os.ReadFile(filepath.Join(prefix, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", path, data, want)
+		}
+	}
+
+	pc, err := os.ReadFile(filepath.Join(prefix, "lib", "pkgconfig", "dave.pc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"prefix=" + filepath.ToSlash(prefix), "Version: v1.1.0", "-Wl,-rpath,${libdir}", "-DLIBDAVE_VERSION=v1.1.0"} {
+		if !strings.Contains(string(pc), want) {
+			t.Errorf("dave.pc does not contain %q:\n%s", want, pc)
+		}
+	}
+}
+
+func TestInstallArchiveChecksumMismatch(t *testing.T) {
+	archive, _ := writeArchive(t, map[string]string{
+		"include/dave/dave.h": "header",
+		"lib/libdave.so":      "library",
+	})
+	prefix := t.TempDir()
+
+	err := run(options{
+		Version:  "v1.1.0",
+		Prefix:   prefix,
+		Archive:  archive,
+		Checksum: strings.Repeat("0", 64),
+		OS:       "linux",
+		Arch:     "amd64",
+	})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(prefix, "lib", "libdave.so")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be installed, got %v", err)
+	}
+}
+
+func TestInstallArchiveMissingFile(t *testing.T) {
+	archive, checksum := writeArchive(t, map[string]string{
+		"include/dave/dave.h": "header",
+	})
+
+	err := run(options{
+		Version:  "v1.1.0",
+		Prefix:   t.TempDir(),
+		Archive:  archive,
+		Checksum: checksum,
+		OS:       "linux",
+		Arch:     "amd64",
+	})
+	if err == nil || !strings.Contains(err.Error(), "lib/libdave.so") {
+		t.Fatalf("expected missing library error, got %v", err)
+	}
+}
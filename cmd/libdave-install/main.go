@@ -0,0 +1,139 @@
+// Command libdave-install installs a prebuilt libdave release and generates the pkg-config file required to build
+// github.com/disgoorg/godave/libdave.
+//
+// Usage:
+//
+//	go run github.com/disgoorg/godave/cmd/libdave-install [flags]
+//
+// Without -version the release required by github.com/disgoorg/godave/libdave is installed, as listed in its release.txt.
+// Pass -archive to install from an already downloaded release archive, for example on machines without internet access.
+// Archives are verified against the SHA-256 checksum given with -sha256, or the checksum published by GitHub for
+// downloaded releases.
+//
+// libdave releases without prebuilt archives for the current platform have to be built from source with the scripts
+// in the scripts directory of this repository.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func main() {
+	var (
+		version    = flag.String("version", "", "libdave release to install, defaults to the release required by github.com/disgoorg/godave/libdave")
+		prefix     = flag.String("prefix", defaultPrefix(), "directory to install libdave into")
+		archive    = flag.String("archive", "", "path of a local release archive to install instead of downloading it")
+		checksum   = flag.String("sha256", "", "expected SHA-256 checksum of the release archive")
+		skipVerify = flag.Bool("skip-verify", false, "install the release archive without verifying its checksum")
+		ssl        = flag.String("ssl", "boringssl", "SSL flavour of the prebuilt release archive")
+		goos       = flag.String("os", runtime.GOOS, "operating system of the release archive")
+		goarch     = flag.String("arch", runtime.GOARCH, "architecture of the release archive")
+	)
+	flag.Parse()
+
+	if err := run(options{
+		Version:    *version,
+		Prefix:     *prefix,
+		Archive:    *archive,
+		Checksum:   *checksum,
+		SkipVerify: *skipVerify,
+		SSL:        *ssl,
+		OS:         *goos,
+		Arch:       *goarch,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+type options struct {
+	Version    string
+	Prefix     string
+	Archive    string
+	Checksum   string
+	SkipVerify bool
+	SSL        string
+	OS         string
+	Arch       string
+}
+
+func run(opts options) error {
+	platform, err := newPlatform(opts.OS, opts.Arch)
+	if err != nil {
+		return err
+	}
+
+	version := opts.Version
+	if version == "" {
+		if version, err = resolveVersion(); err != nil {
+			return fmt.Errorf("failed to resolve libdave version, pass it with -version: %w", err)
+		}
+	}
+	asset := platform.assetName(opts.SSL)
+	logf("Installing libdave %s (%s) into %s", version, asset, opts.Prefix)
+
+	archivePath := opts.Archive
+	checksum := opts.Checksum
+	if archivePath == "" {
+		tag := releaseTag(version)
+		if checksum == "" && !opts.SkipVerify {
+			if checksum, err = fetchChecksum(tag, asset); err != nil {
+				return fmt.Errorf("failed to fetch checksum of %s, pass it with -sha256: %w", asset, err)
+			}
+		}
+
+		tmp, err := os.CreateTemp("", "libdave-*.zip")
+		if err != nil {
+			return err
+		}
+		archivePath = tmp.Name()
+		_ = tmp.Close()
+		defer os.Remove(archivePath)
+
+		url := assetURL(tag, asset)
+		logf("Downloading %s", url)
+		if err = download(url, archivePath); err != nil {
+			return err
+		}
+	}
+
+	sum, err := fileChecksum(archivePath)
+	if err != nil {
+		return err
+	}
+	switch {
+	case checksum != "":
+		if err = verifyChecksum(sum, checksum); err != nil {
+			return err
+		}
+		logf("Verified SHA-256 checksum %s", sum)
+	case opts.SkipVerify:
+		logf("Skipping checksum verification of archive with SHA-256 checksum %s", sum)
+	default:
+		return fmt.Errorf("no checksum to verify %s (SHA-256 %s) against, pass it with -sha256 or use -skip-verify", archivePath, sum)
+	}
+
+	if err = platform.unpack(archivePath, opts.Prefix); err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", archivePath, err)
+	}
+	pcFile, err := platform.writePkgConfig(opts.Prefix, version)
+	if err != nil {
+		return fmt.Errorf("failed to write pkg-config file: %w", err)
+	}
+	logf("Wrote %s", pcFile)
+
+	logf("Installation successful: libdave %s", version)
+	fmt.Println()
+	fmt.Println("Export these environment variables to use the installed libdave:")
+	for _, env := range platform.env(opts.Prefix) {
+		fmt.Printf("    %s\n", env)
+	}
+	return nil
+}
+
+func logf(format string, a ...any) {
+	fmt.Printf("-> "+format+"\n", a...)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	libdaveRepo   = "discord/libdave"
+	libdaveModule = "github.com/disgoorg/godave/libdave"
+	releaseFile   = "release.txt"
+)
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// resolveVersion returns the libdave release listed in the release.txt of github.com/disgoorg/godave/libdave.
+// It looks up the module in the current module graph first and falls back to a checkout of this repository.
+func resolveVersion() (string, error) {
+	var errs []error
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", libdaveModule).Output()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to find module %s: %w", libdaveModule, err))
+	} else if dir := strings.TrimSpace(string(out)); dir != "" {
+		version, err := readVersion(filepath.Join(dir, releaseFile))
+		if err == nil {
+			return version, nil
+		}
+		errs = append(errs, err)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, "libdave", releaseFile)
+		if _, err = os.Stat(path); err == nil {
+			return readVersion(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	errs = append(errs, fmt.Errorf("no libdave/%s found in %s or its parents", releaseFile, dir))
+	return "", errors.Join(errs...)
+}
+
+func readVersion(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	version := strings.TrimSpace(string(data))
+	if version == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return version, nil
+}
+
+// releaseTag returns the tag of the C++ release of the given libdave version.
+func releaseTag(version string) string {
+	return strings.TrimSuffix(version, "/cpp") + "/cpp"
+}
+
+func assetURL(tag string, asset string) string {
+	return fmt.Sprintf("https://github.com/%s/releases/download/%s/%s", libdaveRepo, tag, asset)
+}
+
+// fetchChecksum returns the SHA-256 checksum GitHub publishes for the given release asset.
+func fetchChecksum(tag string, asset string) (string, error) {
+	if commitPattern.MatchString(tag) {
+		return "", fmt.Errorf("%s is a commit, prebuilt releases are only available for tags", tag)
+	}
+
+	rs, err := httpClient.Get(fmt.Sprintf("https://api.github.com/repos/%s/releases/tags/%s", libdaveRepo, url.PathEscape(tag)))
+	if err != nil {
+		return "", err
+	}
+	defer rs.Body.Close()
+	if rs.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch release %s: %s", tag, rs.Status)
+	}
+
+	var release struct {
+		Assets []struct {
+			Name   string `json:"name"`
+			Digest string `json:"digest"`
+		} `json:"assets"`
+	}
+	if err = json.NewDecoder(rs.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to decode release %s: %w", tag, err)
+	}
+	for _, a := range release.Assets {
+		if a.Name != asset {
+			continue
+		}
+		checksum, ok := strings.CutPrefix(a.Digest, "sha256:")
+		if !ok {
+			return "", fmt.Errorf("release %s has no SHA-256 checksum for %s", tag, asset)
+		}
+		return checksum, nil
+	}
+	return "", fmt.Errorf("release %s has no prebuilt archive %s, build libdave from source instead", tag, asset)
+}
+
+func download(url string, path string) error {
+	rs, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer rs.Body.Close()
+	if rs.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, rs.Status)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, rs.Body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,117 @@
+// Command mlsdump decodes the MLS payloads exchanged by DAVE sessions and prints them as JSON.
+//
+// Usage:
+//
+//	mlsdump -type <type> [flags] [file]
+//
+// The payload is read from the given file, or from standard input without one. The type selects the payload:
+//
+//	keypackage      key package sent with SendMLSKeyPackage
+//	externalsender  external sender package passed to OnDaveMLSExternalSenderPackage
+//	proposals       proposals passed to OnDaveMLSProposals
+//	commitwelcome   commit and optional welcome sent with SendMLSCommitWelcome
+//	commit          commit passed to OnDaveMLSPrepareCommitTransition, or any other MLSMessage
+//	welcome         welcome passed to OnDaveMLSWelcome
+//
+// The default auto format accepts hex, base64 as written by slog.JSONHandler for []byte values, and raw binary.
+// Signatures are not verified.
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/disgoorg/godave/mls"
+)
+
+var decoders = map[string]func(data []byte) (any, error){
+	"keypackage": func(data []byte) (any, error) {
+		return mls.DecodeKeyPackage(data)
+	},
+	"externalsender": func(data []byte) (any, error) {
+		return mls.DecodeExternalSender(data)
+	},
+	"proposals": func(data []byte) (any, error) {
+		return mls.DecodeProposals(data)
+	},
+	"commitwelcome": func(data []byte) (any, error) {
+		return mls.DecodeCommitWelcome(data)
+	},
+	"commit": func(data []byte) (any, error) {
+		return mls.DecodeMLSMessage(data)
+	},
+	"welcome": func(data []byte) (any, error) {
+		return mls.DecodeWelcome(data)
+	},
+}
+
+func main() {
+	typ := flag.String("type", "", "payload type: keypackage, externalsender, proposals, commitwelcome, commit or welcome")
+	format := flag.String("format", "auto", "input format: auto, hex, base64 or binary")
+	flag.Parse()
+
+	if err := run(*typ, *format, flag.Arg(0), os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(typ string, format string, file string, out io.Writer) error {
+	decode, ok := decoders[typ]
+	if !ok {
+		return fmt.Errorf("unknown type %q", typ)
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if file == "" || file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	if data, err = decodeInput(format, data); err != nil {
+		return err
+	}
+
+	value, err := decode(data)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func decodeInput(format string, data []byte) ([]byte, error) {
+	text := strings.Join(strings.Fields(strings.ReplaceAll(string(data), ":", "")), "")
+	switch format {
+	case "binary":
+		return data, nil
+	case "hex":
+		return hex.DecodeString(text)
+	case "base64":
+		return base64.StdEncoding.DecodeString(strings.Trim(text, `"`))
+	case "auto":
+		if decoded, err := hex.DecodeString(text); err == nil {
+			return decoded, nil
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(strings.Trim(text, `"`)); err == nil {
+			return decoded, nil
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
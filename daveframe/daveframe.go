@@ -0,0 +1,213 @@
+// Package daveframe parses and validates frames encrypted with the DAVE protocol.
+//
+// An encrypted frame consists of the media payload, in which the encrypted bytes are interleaved with the
+// unencrypted ranges the codec requires to stay readable, followed by the supplemental data:
+//
+//	payload | tag (8 bytes) | nonce (ULEB128) | unencrypted ranges (ULEB128 offset and size pairs) | supplemental data size (1 byte) | magic marker (0xFAFA)
+//
+// The supplemental data size covers everything after the payload, including itself and the magic marker.
+package daveframe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// MagicMarker marks the end of every encrypted frame.
+	MagicMarker uint16 = 0xFAFA
+	// TagSize is the size of the truncated AES-GCM authentication tag.
+	TagSize = 8
+
+	magicMarkerSize = 2
+	// minSupplementalDataSize is the size of the supplemental data with a single byte nonce and no unencrypted ranges.
+	minSupplementalDataSize = TagSize + 1 + 1 + magicMarkerSize
+)
+
+var (
+	ErrMissingMagicMarker          = errors.New("daveframe: missing magic marker")
+	ErrInvalidSupplementalDataSize = errors.New("daveframe: invalid supplemental data size")
+	ErrMalformedNonce              = errors.New("daveframe: malformed nonce")
+	ErrMalformedUnencryptedRanges  = errors.New("daveframe: malformed unencrypted ranges")
+)
+
+// opusSilenceFrame is the Opus frame sent during silence, it is never encrypted.
+var opusSilenceFrame = []byte{0xF8, 0xFF, 0xFE}
+
+// Range is a range of the payload.
+type Range struct {
+	// Offset is the offset of the range in the payload.
+	Offset int `json:"offset"`
+	// Size is the number of bytes in the range.
+	Size int `json:"size"`
+}
+
+// End returns the offset after the last byte of the range.
+func (r Range) End() int {
+	return r.Offset + r.Size
+}
+
+// Frame is a parsed DAVE encrypted frame.
+type Frame struct {
+	// Payload is the media payload with the encrypted bytes interleaved with the UnencryptedRanges.
+	Payload []byte
+	// Tag is the truncated AES-GCM authentication tag.
+	Tag []byte
+	// Nonce is the truncated nonce the frame was encrypted with.
+	Nonce uint32
+	// UnencryptedRanges are the ranges of the payload which are not encrypted, ordered by offset.
+	UnencryptedRanges []Range
+	// SupplementalDataSize is the size of everything following the payload.
+	SupplementalDataSize int
+}
+
+// Generation returns the key ratchet generation the frame was encrypted with, stored in the most significant byte of the nonce.
+func (f *Frame) Generation() uint8 {
+	return uint8(f.Nonce >> 24)
+}
+
+// UnencryptedSize returns the number of payload bytes which are not encrypted.
+func (f *Frame) UnencryptedSize() int {
+	var size int
+	for _, r := range f.UnencryptedRanges {
+		size += r.Size
+	}
+	return size
+}
+
+// EncryptedSize returns the number of payload bytes which are encrypted.
+func (f *Frame) EncryptedSize() int {
+	return len(f.Payload) - f.UnencryptedSize()
+}
+
+// Size returns the size of the encoded frame.
+func (f *Frame) Size() int {
+	return len(f.Payload) + f.SupplementalDataSize
+}
+
+// AppendBinary appends the encoded frame to b. SupplementalDataSize is ignored and computed from the other fields.
+func (f *Frame) AppendBinary(b []byte) ([]byte, error) {
+	if len(f.Tag) != TagSize {
+		return b, fmt.Errorf("daveframe: tag must be %d bytes, got %d", TagSize, len(f.Tag))
+	}
+	if err := validateRanges(f.UnencryptedRanges, len(f.Payload)); err != nil {
+		return b, err
+	}
+
+	start := len(b)
+	b = append(b, f.Payload...)
+	b = append(b, f.Tag...)
+	b = appendULEB128(b, uint64(f.Nonce))
+	for _, r := range f.UnencryptedRanges {
+		b = appendULEB128(b, uint64(r.Offset))
+		b = appendULEB128(b, uint64(r.Size))
+	}
+
+	supplementalDataSize := len(b) - start - len(f.Payload) + 1 + magicMarkerSize
+	if supplementalDataSize > math.MaxUint8 {
+		return b[:start], fmt.Errorf("%w: %d exceeds %d bytes", ErrInvalidSupplementalDataSize, supplementalDataSize, math.MaxUint8)
+	}
+	b = append(b, byte(supplementalDataSize))
+	return binary.BigEndian.AppendUint16(b, MagicMarker), nil
+}
+
+// HasMagicMarker reports whether data ends with the MagicMarker.
+// Frames without it are passed through unencrypted.
+func HasMagicMarker(data []byte) bool {
+	return len(data) >= magicMarkerSize && binary.BigEndian.Uint16(data[len(data)-magicMarkerSize:]) == MagicMarker
+}
+
+// IsOpusSilence reports whether data is the Opus silence frame, which is never encrypted.
+func IsOpusSilence(data []byte) bool {
+	return string(data) == string(opusSilenceFrame)
+}
+
+// Parse parses and validates an encrypted frame. The returned Frame references data.
+func Parse(data []byte) (*Frame, error) {
+	if !HasMagicMarker(data) {
+		return nil, ErrMissingMagicMarker
+	}
+
+	supplementalDataSize := int(data[len(data)-magicMarkerSize-1])
+	if supplementalDataSize < minSupplementalDataSize || supplementalDataSize > len(data) {
+		return nil, fmt.Errorf("%w: %d bytes in a frame of %d bytes", ErrInvalidSupplementalDataSize, supplementalDataSize, len(data))
+	}
+
+	payloadSize := len(data) - supplementalDataSize
+	frame := &Frame{
+		Payload:              data[:payloadSize],
+		Tag:                  data[payloadSize : payloadSize+TagSize],
+		SupplementalDataSize: supplementalDataSize,
+	}
+
+	// nonce and ranges are between the tag and the supplemental data size byte
+	rest := data[payloadSize+TagSize : len(data)-magicMarkerSize-1]
+	nonce, n := readULEB128(rest)
+	if n <= 0 || nonce > math.MaxUint32 {
+		return nil, ErrMalformedNonce
+	}
+	frame.Nonce = uint32(nonce)
+	rest = rest[n:]
+
+	for len(rest) > 0 {
+		offset, n := readULEB128(rest)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: malformed offset of range %d", ErrMalformedUnencryptedRanges, len(frame.UnencryptedRanges))
+		}
+		rest = rest[n:]
+		size, n := readULEB128(rest)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: malformed size of range %d", ErrMalformedUnencryptedRanges, len(frame.UnencryptedRanges))
+		}
+		rest = rest[n:]
+		if offset > uint64(payloadSize) || size > uint64(payloadSize) {
+			return nil, fmt.Errorf("%w: range %d exceeds the payload of %d bytes", ErrMalformedUnencryptedRanges, len(frame.UnencryptedRanges), payloadSize)
+		}
+		frame.UnencryptedRanges = append(frame.UnencryptedRanges, Range{Offset: int(offset), Size: int(size)})
+	}
+
+	if err := validateRanges(frame.UnencryptedRanges, payloadSize); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// validateRanges checks that the ranges are ordered, do not overlap and are within the payload.
+func validateRanges(ranges []Range, payloadSize int) error {
+	end := 0
+	for i, r := range ranges {
+		if r.Offset < end {
+			return fmt.Errorf("%w: range %d at offset %d overlaps or precedes the previous range ending at %d", ErrMalformedUnencryptedRanges, i, r.Offset, end)
+		}
+		if r.Size < 0 || r.End() > payloadSize {
+			return fmt.Errorf("%w: range %d ending at %d exceeds the payload of %d bytes", ErrMalformedUnencryptedRanges, i, r.End(), payloadSize)
+		}
+		end = r.End()
+	}
+	return nil
+}
+
+func appendULEB128(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// readULEB128 returns the value and the number of bytes read, or 0 if data is truncated or the value overflows.
+func readULEB128(data []byte) (uint64, int) {
+	var v uint64
+	for i, b := range data {
+		if i == 10 || (i == 9 && b > 1) {
+			return 0, 0
+		}
+		v |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,81 @@
+package daveframe
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	frame := &Frame{
+		Payload: bytes.Repeat([]byte{0xAB}, 300),
+		Tag:     []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Nonce:   0x02000105,
+		UnencryptedRanges: []Range{
+			{Offset: 0, Size: 4},
+			{Offset: 200, Size: 150 - 50},
+		},
+	}
+
+	data, err := frame.AppendBinary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Payload, frame.Payload) || !bytes.Equal(parsed.Tag, frame.Tag) || parsed.Nonce != frame.Nonce {
+		t.Errorf("expected %+v, got %+v", frame, parsed)
+	}
+	if len(parsed.UnencryptedRanges) != 2 || parsed.UnencryptedRanges[1] != frame.UnencryptedRanges[1] {
+		t.Errorf("expected ranges %v, got %v", frame.UnencryptedRanges, parsed.UnencryptedRanges)
+	}
+	if parsed.Size() != len(data) {
+		t.Errorf("expected size %d, got %d", len(data), parsed.Size())
+	}
+	if parsed.Generation() != 2 {
+		t.Errorf("expected generation 2, got %d", parsed.Generation())
+	}
+	if parsed.EncryptedSize() != 196 {
+		t.Errorf("expected 196 encrypted bytes, got %d", parsed.EncryptedSize())
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	valid, err := (&Frame{Payload: []byte{1, 2, 3}, Tag: make([]byte, TagSize), Nonce: 1}).AppendBinary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// payload 3 bytes, tag 8 bytes, nonce at 11, size at 12, magic at 13
+	withNonce := func(nonce ...byte) []byte {
+		data := append(bytes.Clone(valid[:11]), nonce...)
+		// the supplemental data size counts everything but the 3 byte payload, including itself and the magic marker
+		return append(data, byte(len(data)), 0xFA, 0xFA)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "empty", data: nil, err: ErrMissingMagicMarker},
+		{name: "plaintext", data: []byte{0xF8, 0xFF, 0xFE}, err: ErrMissingMagicMarker},
+		{name: "size exceeds frame", data: append(bytes.Clone(valid[:len(valid)-3]), 0xFF, 0xFA, 0xFA), err: ErrInvalidSupplementalDataSize},
+		{name: "size too small", data: append(bytes.Clone(valid[:len(valid)-3]), 4, 0xFA, 0xFA), err: ErrInvalidSupplementalDataSize},
+		{name: "truncated nonce", data: withNonce(0x80), err: ErrMalformedNonce},
+		{name: "nonce overflow", data: withNonce(0xFF, 0xFF, 0xFF, 0xFF, 0x7F), err: ErrMalformedNonce},
+		{name: "truncated range", data: withNonce(1, 0), err: ErrMalformedUnencryptedRanges},
+		{name: "range exceeds payload", data: withNonce(1, 2, 2), err: ErrMalformedUnencryptedRanges},
+		{name: "overlapping ranges", data: withNonce(1, 0, 2, 1, 1), err: ErrMalformedUnencryptedRanges},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.data); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Package davelog provides slog.LogValuer types to log DAVE payloads without leaking secrets.
+//
+// The types log the size and a truncated SHA-256 hash of a payload, to correlate it across logs of both ends of a
+// call, and the non-secret header fields decoded with the mls and daveframe packages. Payload bytes are never logged:
+//
+//	logger.Debug("received MLS proposals", slog.Any("proposals", davelog.Proposals(proposals)))
+//
+// The values are only computed if the record is logged, so they are cheap to pass to disabled levels.
+package davelog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/disgoorg/godave/daveframe"
+	"github.com/disgoorg/godave/mls"
+)
+
+// hashSize is the number of bytes of the SHA-256 hash logged.
+const hashSize = 8
+
+var (
+	_ slog.LogValuer = Secret(nil)
+	_ slog.LogValuer = KeyPackage(nil)
+	_ slog.LogValuer = ExternalSender(nil)
+	_ slog.LogValuer = Proposals(nil)
+	_ slog.LogValuer = CommitWelcome(nil)
+	_ slog.LogValuer = Commit(nil)
+	_ slog.LogValuer = Welcome(nil)
+	_ slog.LogValuer = Frame(nil)
+)
+
+// Hash returns the truncated hex encoded SHA-256 hash of data logged by the types of this package.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:hashSize])
+}
+
+func payloadAttrs(data []byte, attrs ...slog.Attr) slog.Value {
+	return slog.GroupValue(append([]slog.Attr{
+		slog.Int("size", len(data)),
+		slog.String("sha256", Hash(data)),
+	}, attrs...)...)
+}
+
+func errorAttrs(data []byte, err error) slog.Value {
+	return payloadAttrs(data, slog.String("error", err.Error()))
+}
+
+// Secret is key material. Only its size is logged.
+type Secret []byte
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue("REDACTED (" + strconv.Itoa(len(s)) + " bytes)")
+}
+
+// KeyPackage is a MLS key package, as sent with godave.Callbacks.SendMLSKeyPackage.
+type KeyPackage []byte
+
+func (k KeyPackage) LogValue() slog.Value {
+	keyPackage, err := mls.DecodeKeyPackage(k)
+	if err != nil {
+		return errorAttrs(k, err)
+	}
+	return payloadAttrs(k,
+		slog.String("cipher_suite", keyPackage.CipherSuite.String()),
+		credentialAttr(keyPackage.LeafNode.Credential),
+		slog.String("signature_key", Hash(keyPackage.LeafNode.SignatureKey)),
+	)
+}
+
+// ExternalSender is the external sender package passed to godave.Session.OnDaveMLSExternalSenderPackage.
+type ExternalSender []byte
+
+func (e ExternalSender) LogValue() slog.Value {
+	externalSender, err := mls.DecodeExternalSender(e)
+	if err != nil {
+		return errorAttrs(e, err)
+	}
+	return payloadAttrs(e,
+		credentialAttr(externalSender.Credential),
+		slog.String("signature_key", Hash(externalSender.SignatureKey)),
+	)
+}
+
+// Proposals are the proposals passed to godave.Session.OnDaveMLSProposals.
+type Proposals []byte
+
+func (p Proposals) LogValue() slog.Value {
+	proposals, err := mls.DecodeProposals(p)
+	if err != nil {
+		return errorAttrs(p, err)
+	}
+	attrs := []slog.Attr{slog.String("operation", proposals.Operation.String())}
+	if proposals.Operation == mls.ProposalsOperationRevoke {
+		return payloadAttrs(p, append(attrs, slog.Int("refs", len(proposals.Refs)))...)
+	}
+
+	var epoch uint64
+	summaries := make([]string, 0, len(proposals.Messages))
+	for _, message := range proposals.Messages {
+		if message.PublicMessage == nil || message.PublicMessage.Content.Proposal == nil {
+			summaries = append(summaries, message.WireFormat.String())
+			continue
+		}
+		epoch = message.PublicMessage.Content.Epoch
+		summaries = append(summaries, proposalSummary(message.PublicMessage.Content.Proposal))
+	}
+	return payloadAttrs(p, append(attrs,
+		slog.Uint64("epoch", epoch),
+		slog.String("proposals", strings.Join(summaries, ",")),
+	)...)
+}
+
+// CommitWelcome is the commit and optional welcome sent with godave.Callbacks.SendMLSCommitWelcome.
+type CommitWelcome []byte
+
+func (c CommitWelcome) LogValue() slog.Value {
+	commitWelcome, err := mls.DecodeCommitWelcome(c)
+	if err != nil {
+		return errorAttrs(c, err)
+	}
+	attrs := messageAttrs(commitWelcome.Commit)
+	if commitWelcome.Welcome != nil {
+		attrs = append(attrs, slog.Int("welcomed", len(commitWelcome.Welcome.Secrets)))
+	}
+	return payloadAttrs(c, attrs...)
+}
+
+// Commit is the commit passed to godave.Session.OnDaveMLSPrepareCommitTransition.
+type Commit []byte
+
+func (c Commit) LogValue() slog.Value {
+	message, err := mls.DecodeMLSMessage(c)
+	if err != nil {
+		return errorAttrs(c, err)
+	}
+	return payloadAttrs(c, messageAttrs(message)...)
+}
+
+// Welcome is the welcome passed to godave.Session.OnDaveMLSWelcome.
+type Welcome []byte
+
+func (w Welcome) LogValue() slog.Value {
+	welcome, err := mls.DecodeWelcome(w)
+	if err != nil {
+		return errorAttrs(w, err)
+	}
+	return payloadAttrs(w,
+		slog.String("cipher_suite", welcome.CipherSuite.String()),
+		slog.Int("welcomed", len(welcome.Secrets)),
+	)
+}
+
+// Frame is an encrypted or passed through media frame.
+type Frame []byte
+
+func (f Frame) LogValue() slog.Value {
+	frame, err := daveframe.Parse(f)
+	if err != nil {
+		return slog.GroupValue(
+			slog.Int("size", len(f)),
+			slog.Bool("encrypted", false),
+			slog.String("error", err.Error()),
+		)
+	}
+	return slog.GroupValue(
+		slog.Int("size", len(f)),
+		slog.Bool("encrypted", true),
+		slog.Uint64("nonce", uint64(frame.Nonce)),
+		slog.Int("generation", int(frame.Generation())),
+		slog.Int("unencrypted_ranges", len(frame.UnencryptedRanges)),
+	)
+}
+
+func credentialAttr(credential mls.Credential) slog.Attr {
+	if userID, ok := credential.UserID(); ok {
+		return slog.String("user_id", string(userID))
+	}
+	return slog.String("credential_type", credential.CredentialType.String())
+}
+
+func messageAttrs(message *mls.MLSMessage) []slog.Attr {
+	attrs := []slog.Attr{slog.String("wire_format", message.WireFormat.String())}
+	if message.PublicMessage == nil {
+		return attrs
+	}
+
+	content := message.PublicMessage.Content
+	attrs = append(attrs,
+		slog.Uint64("epoch", content.Epoch),
+		slog.String("sender", content.Sender.SenderType.String()),
+		slog.String("content_type", content.ContentType.String()),
+	)
+	if content.Sender.LeafIndex != nil {
+		attrs = append(attrs, slog.Uint64("leaf_index", uint64(*content.Sender.LeafIndex)))
+	}
+	if content.Commit != nil {
+		summaries := make([]string, len(content.Commit.Proposals))
+		for i, proposal := range content.Commit.Proposals {
+			if proposal.Proposal != nil {
+				summaries[i] = proposalSummary(proposal.Proposal)
+			} else {
+				summaries[i] = "ref:" + Hash(proposal.Reference)
+			}
+		}
+		attrs = append(attrs,
+			slog.String("proposals", strings.Join(summaries, ",")),
+			slog.Bool("path", content.Commit.Path != nil),
+		)
+	}
+	return attrs
+}
+
+// proposalSummary returns the type of the proposal and the user or leaf it adds or removes.
+func proposalSummary(proposal *mls.Proposal) string {
+	switch {
+	case proposal.Add != nil:
+		if userID, ok := proposal.Add.LeafNode.Credential.UserID(); ok {
+			return "add:" + string(userID)
+		}
+	case proposal.Remove != nil:
+		return "remove:" + strconv.FormatUint(uint64(*proposal.Remove), 10)
+	}
+	return proposal.ProposalType.String()
+}
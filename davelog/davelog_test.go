@@ -0,0 +1,61 @@
+package davelog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/disgoorg/godave/daveframe"
+)
+
+func logged(t *testing.T, key string, value slog.LogValuer) string {
+	t.Helper()
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("test", slog.Any(key, value))
+	return buf.String()
+}
+
+func TestSecret(t *testing.T) {
+	secret := bytes.Repeat([]byte{0xab}, 16)
+	output := logged(t, "secret", Secret(secret))
+	if strings.Contains(output, hex.EncodeToString(secret)) || !strings.Contains(output, `secret="REDACTED (16 bytes)"`) {
+		t.Errorf("expected redacted secret, got %s", output)
+	}
+}
+
+func TestProposals(t *testing.T) {
+	ref := bytes.Repeat([]byte{0xcd}, 32)
+	// revoke operation with one proposal ref
+	proposals := append([]byte{1, 33, 32}, ref...)
+	output := logged(t, "proposals", Proposals(proposals))
+	for _, want := range []string{"proposals.size=35", "proposals.sha256=" + Hash(proposals), "proposals.operation=revoke", "proposals.refs=1"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %s, got %s", want, output)
+		}
+	}
+	if strings.Contains(output, hex.EncodeToString(ref)) {
+		t.Errorf("expected no payload bytes, got %s", output)
+	}
+
+	output = logged(t, "proposals", Proposals(proposals[:10]))
+	if !strings.Contains(output, "proposals.error=") || !strings.Contains(output, "proposals.size=10") {
+		t.Errorf("expected decode error, got %s", output)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	frame := &daveframe.Frame{Payload: []byte("opus frame"), Tag: make([]byte, daveframe.TagSize), Nonce: 2<<24 | 5}
+	data, err := frame.AppendBinary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := logged(t, "frame", Frame(data))
+	for _, want := range []string{"frame.encrypted=true", "frame.generation=2", "frame.nonce=33554437"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %s, got %s", want, output)
+		}
+	}
+}
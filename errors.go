@@ -0,0 +1,8 @@
+package godave
+
+import (
+	"errors"
+)
+
+// ErrNotReady is returned by sessions running in strict mode when a frame is encrypted before an E2EE epoch is established.
+var ErrNotReady = errors.New("session has no established E2EE epoch")
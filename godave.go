@@ -31,6 +31,12 @@ const (
 	CodecOpus Codec = 1
 )
 
+// VersionProvider is implemented by Sessions that expose the DAVE protocol version negotiated for the current epoch.
+type VersionProvider interface {
+	// ProtocolVersion returns the DAVE protocol version of the current E2EE epoch, or 0 while not encrypting.
+	ProtocolVersion() int
+}
+
 // Session is an interface representing a DAVE session.
 // Implementations of this interface should handle encryption, decryption, and DAVE protocol events.
 type Session interface {
@@ -7,19 +7,82 @@ import (
 var (
 	_ SessionCreateFunc = NewNoopSession
 	_ Session           = (*noopSession)(nil)
+	_ VersionProvider   = (*noopSession)(nil)
+	_ AppendSession     = (*noopSession)(nil)
 )
 
+// NewNoopSession returns a Session which does not encrypt or decrypt frames, using the DefaultNoopConfig.
 func NewNoopSession(logger *slog.Logger, _ UserID, _ Callbacks) Session {
-	logger.Warn("Using noop dave session. Please migrate to an implementation of libdave or your audio connections will stop working on 01.03.2026")
+	return newNoopSession(logger, DefaultNoopConfig())
+}
+
+// NewNoopSessionCreateFunc returns a SessionCreateFunc creating noop sessions configured with the given NoopConfigOpt(s).
+// It returns an error if the resulting NoopConfig is invalid.
+func NewNoopSessionCreateFunc(opts ...NoopConfigOpt) (SessionCreateFunc, error) {
+	config := DefaultNoopConfig()
+	config.Apply(opts)
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return func(logger *slog.Logger, _ UserID, _ Callbacks) Session {
+		return newNoopSession(logger, config)
+	}, nil
+}
+
+func newNoopSession(logger *slog.Logger, config *NoopConfig) Session {
+	if config.Warn {
+		logger.Warn("Using noop dave session. Please migrate to an implementation of libdave or your audio connections will stop working on 01.03.2026")
+	}
 
 	return &noopSession{}
 }
 
+// DefaultNoopConfig returns a NoopConfig with sensible defaults.
+func DefaultNoopConfig() *NoopConfig {
+	return &NoopConfig{
+		Warn: true,
+	}
+}
+
+// NoopConfig is the configuration used by sessions created with NewNoopSessionCreateFunc.
+type NoopConfig struct {
+	// Warn logs a warning when a noop session is created.
+	Warn bool
+}
+
+// NoopConfigOpt is a type alias for a function that takes a NoopConfig and is used to configure your noop sessions.
+type NoopConfigOpt func(config *NoopConfig)
+
+// Apply applies the given NoopConfigOpt(s) to the NoopConfig.
+func (c *NoopConfig) Apply(opts []NoopConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// Validate reports whether the NoopConfig contains incompatible settings.
+// Noop sessions support every setting of the NoopConfig, so it is always valid.
+func (c *NoopConfig) Validate() error {
+	return nil
+}
+
+// WithNoopWarning sets whether a warning is logged when a noop session is created.
+func WithNoopWarning(warn bool) NoopConfigOpt {
+	return func(config *NoopConfig) {
+		config.Warn = warn
+	}
+}
+
 type noopSession struct{}
 
 func (n *noopSession) MaxSupportedProtocolVersion() int {
 	return 0
 }
+func (n *noopSession) ProtocolVersion() int {
+	return 0
+}
 func (n *noopSession) Ready() bool {
 	return true
 }
@@ -39,6 +102,12 @@ func (n *noopSession) MaxDecryptedFrameSize(_ UserID, frameSize int) int {
 func (n *noopSession) Decrypt(_ UserID, frame []byte, decryptedFrame []byte) (int, error) {
 	return copy(decryptedFrame, frame), nil
 }
+func (n *noopSession) EncryptAppend(dst []byte, _ uint32, frame []byte) ([]byte, error) {
+	return append(dst, frame...), nil
+}
+func (n *noopSession) DecryptAppend(dst []byte, _ UserID, frame []byte) ([]byte, error) {
+	return append(dst, frame...), nil
+}
 func (n *noopSession) SetChannelID(_ ChannelID)                            {}
 func (n *noopSession) AssignSsrcToCodec(_ uint32, _ Codec)                 {}
 func (n *noopSession) AddUser(_ UserID)                                    {}
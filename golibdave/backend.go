@@ -0,0 +1,104 @@
+package golibdave
+
+import (
+	"github.com/disgoorg/godave/libdave"
+)
+
+// backend are the libdave objects driven by a session. Tests replace them with fakes to check the
+// state machine without a native libdave.
+type backend struct {
+	maxProtocolVersion uint16
+	session            mlsSession
+	encryptor          encryptor
+	newDecryptor       func() decryptor
+}
+
+// mlsSession is implemented by libdaveSession.
+type mlsSession interface {
+	Init(version uint16, channelID uint64, selfUserID string)
+	Reset()
+	GetProtocolVersion() uint16
+	SetExternalSender(externalSender []byte)
+	ProcessProposals(proposals []byte, recognizedUserIDs []string) []byte
+	ProcessCommit(commit []byte) commitResult
+	// ProcessWelcome returns nil if the welcome could not be processed.
+	ProcessWelcome(welcome []byte, recognizedUserIDs []string) rosterResult
+	GetMarshalledKeyPackage() []byte
+	GetKeyRatchet(userID string) *libdave.KeyRatchet
+}
+
+// commitResult is implemented by libdave.CommitResult.
+type commitResult interface {
+	rosterResult
+	IsFailed() bool
+	IsIgnored() bool
+}
+
+// encryptor is implemented by libdave.Encryptor.
+type encryptor interface {
+	HasKeyRatchet() bool
+	IsPassthroughMode() bool
+	SetKeyRatchet(keyRatchet *libdave.KeyRatchet)
+	SetPassthroughMode(passthroughMode bool)
+	AssignSsrcToCodec(ssrc uint32, codec libdave.Codec)
+	GetProtocolVersion() uint16
+	GetMaxCiphertextByteSize(mediaType libdave.MediaType, frameSize int) int
+	Encrypt(mediaType libdave.MediaType, ssrc uint32, frame []byte, encryptedFrame []byte) (int, error)
+	EncryptAppend(dst []byte, mediaType libdave.MediaType, ssrc uint32, frame []byte) ([]byte, error)
+	EncryptBatch(frames []libdave.EncryptFrame)
+}
+
+// decryptor is implemented by libdave.Decryptor.
+type decryptor interface {
+	TransitionToKeyRatchet(keyRatchet *libdave.KeyRatchet)
+	TransitionToPassthroughMode(passthroughMode bool)
+	GetMaxPlaintextByteSize(mediaType libdave.MediaType, encryptedFrameSize int) int
+	Decrypt(mediaType libdave.MediaType, frame []byte, decryptedFrame []byte) (int, error)
+	DecryptAppend(dst []byte, mediaType libdave.MediaType, frame []byte) ([]byte, error)
+}
+
+var (
+	_ mlsSession = libdaveSession{}
+	_ encryptor  = (*libdave.Encryptor)(nil)
+	_ decryptor  = (*libdave.Decryptor)(nil)
+)
+
+// newLibdaveBackend returns the backend of sessions using libdave.
+func newLibdaveBackend(config *Config) backend {
+	encryptor := libdave.NewEncryptor()
+	// Start in Passthrough by default
+	encryptor.SetPassthroughMode(true)
+
+	// Context and authSessionID are only used with persistent key storage
+	var keyContext, authSessionID string
+	if config.PersistentKeys != nil {
+		keyContext = config.PersistentKeys.Context
+		authSessionID = config.PersistentKeys.AuthSessionID
+	}
+
+	return backend{
+		maxProtocolVersion: libdave.MaxSupportedProtocolVersion(),
+		session:            libdaveSession{Session: libdave.NewSession(keyContext, authSessionID)},
+		encryptor:          encryptor,
+		newDecryptor: func() decryptor {
+			return libdave.NewDecryptor()
+		},
+	}
+}
+
+// libdaveSession adapts libdave.Session to mlsSession.
+type libdaveSession struct {
+	*libdave.Session
+}
+
+func (s libdaveSession) ProcessCommit(commit []byte) commitResult {
+	return s.Session.ProcessCommit(commit)
+}
+
+func (s libdaveSession) ProcessWelcome(welcome []byte, recognizedUserIDs []string) rosterResult {
+	// a nil *libdave.WelcomeResult must not become a non-nil rosterResult
+	if res := s.Session.ProcessWelcome(welcome, recognizedUserIDs); res != nil {
+		return res
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package golibdave
+
+import (
+	"github.com/disgoorg/godave"
+	"github.com/disgoorg/godave/libdave"
+)
+
+func (s *session) EncryptBatch(frames []godave.EncryptFrame) {
+	if s.config.StrictMode && !s.Ready() {
+		for i := range frames {
+			frames[i].N, frames[i].Err = 0, godave.ErrNotReady
+		}
+		return
+	}
+
+	libdaveFrames := make([]libdave.EncryptFrame, len(frames))
+	for i, frame := range frames {
+		libdaveFrames[i] = libdave.EncryptFrame{
+			MediaType:      libdave.MediaTypeAudio,
+			SSRC:           frame.SSRC,
+			Frame:          frame.Frame,
+			EncryptedFrame: frame.EncryptedFrame,
+		}
+	}
+
+	s.encryptor.EncryptBatch(libdaveFrames)
+
+	for i, frame := range libdaveFrames {
+		frames[i].N, frames[i].Err = frame.N, frame.Err
+	}
+}
+
+func (s *session) DecryptBatch(frames []godave.DecryptFrame) {
+	libdaveFrames := make([]libdave.DecryptFrame, 0, len(frames))
+	// indices maps libdaveFrames to frames
+	indices := make([]int, 0, len(frames))
+
+	s.decryptorsMu.RLock()
+	for i := range frames {
+		frame := &frames[i]
+		decryptor, ok := s.decryptors[frame.UserID]
+		if !ok {
+			// assume passthrough
+			frame.N, frame.Err = copy(frame.DecryptedFrame, frame.Frame), nil
+			continue
+		}
+
+		libdaveDecryptor, ok := decryptor.(*libdave.Decryptor)
+		if !ok {
+			// only libdave decryptors can be batched
+			frame.N, frame.Err = decryptor.Decrypt(libdave.MediaTypeAudio, frame.Frame, frame.DecryptedFrame)
+			continue
+		}
+
+		libdaveFrames = append(libdaveFrames, libdave.DecryptFrame{
+			Decryptor:      libdaveDecryptor,
+			MediaType:      libdave.MediaTypeAudio,
+			Frame:          frame.Frame,
+			DecryptedFrame: frame.DecryptedFrame,
+		})
+		indices = append(indices, i)
+	}
+	s.decryptorsMu.RUnlock()
+
+	libdave.DecryptBatch(libdaveFrames)
+
+	for i, frame := range libdaveFrames {
+		frames[indices[i]].N, frames[indices[i]].Err = frame.N, frame.Err
+	}
+}
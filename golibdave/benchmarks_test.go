@@ -0,0 +1,13 @@
+package golibdave
+
+import (
+	"testing"
+
+	"github.com/disgoorg/godave/benchmarks"
+)
+
+// BenchmarkSession runs the godave benchmark suite against libdave sessions.
+// Compare runs with benchstat, for example before and after bumping libdave.
+func BenchmarkSession(b *testing.B) {
+	benchmarks.Run(b, NewSession)
+}
@@ -0,0 +1,72 @@
+// Command davereplay replays a transcript recorded with the transcript package against libdave and prints the calls
+// after which the callbacks of libdave differ from the recorded ones.
+//
+// Usage:
+//
+//	davereplay [-v] [file]
+//
+// The transcript is read from the given file, or from standard input without one. davereplay exits with status 1
+// if the callbacks differ.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/disgoorg/godave/golibdave"
+	"github.com/disgoorg/godave/transcript"
+)
+
+var errDiffs = errors.New("replay differs from the transcript")
+
+func main() {
+	verbose := flag.Bool("v", false, "log the replayed sessions")
+	flag.Parse()
+
+	if err := run(flag.Arg(0), *verbose, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(file string, verbose bool, out io.Writer) error {
+	in := os.Stdin
+	if file != "" && file != "-" {
+		var err error
+		if in, err = os.Open(file); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+	events, err := transcript.Read(in)
+	if err != nil {
+		return err
+	}
+
+	createSession, err := golibdave.NewSessionCreateFunc()
+	if err != nil {
+		return err
+	}
+	handler := slog.DiscardHandler
+	if verbose {
+		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	}
+
+	diffs, err := transcript.Replay(createSession, slog.New(handler), events)
+	for _, diff := range diffs {
+		if _, err := fmt.Fprintln(out, diff); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%w: %d calls", errDiffs, len(diffs))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Command godave-sidecar hosts golibdave sessions for clients of the sidecar package connected over a Unix socket.
+//
+// Usage:
+//
+//	godave-sidecar [-socket path] [-v]
+//
+// A stale socket file left by a previous run is removed. godave-sidecar stops on SIGINT or SIGTERM and closes
+// the sessions of all clients.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/disgoorg/godave/golibdave"
+	"github.com/disgoorg/godave/sidecar"
+)
+
+func main() {
+	socket := flag.String("socket", sidecar.DefaultSocketPath, "path of the Unix socket to listen on")
+	verbose := flag.Bool("v", false, "log DAVE state transitions of the sessions")
+	flag.Parse()
+
+	if err := run(*socket, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(socket string, verbose bool) error {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	createSession, err := golibdave.NewSessionCreateFunc()
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(socket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+
+	server := sidecar.NewServer(createSession, logger)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logger.Info("shutting down", slog.String("signal", sig.String()))
+		_ = server.Close()
+	}()
+
+	logger.Info("listening", slog.String("socket", socket))
+	if err = server.Serve(l); !errors.Is(err, sidecar.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package golibdave
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/disgoorg/godave"
+	"github.com/disgoorg/godave/libdave"
+)
+
+var (
+	ErrMissingAuthSessionID       = errors.New("golibdave: persistent keys require an auth session ID")
+	ErrMissingClock               = errors.New("golibdave: clock must not be nil")
+	ErrUnsupportedProtocolVersion = errors.New("golibdave: protocol version not supported by libdave")
+)
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		Clock: time.Now,
+	}
+}
+
+// Config is the configuration used by sessions created with NewSessionCreateFunc.
+type Config struct {
+	// SecurityEventHandler is called for every godave.SecurityEvent emitted by the session.
+	SecurityEventHandler godave.SecurityEventHandler
+	// AdmissionPolicy decides which users are recognized as members of the MLS group. Nil admits everyone.
+	AdmissionPolicy godave.AdmissionPolicy
+	// RosterUpdateHandler is called for every change of the MLS group roster.
+	RosterUpdateHandler godave.RosterUpdateHandler
+	// PersistentKeys enables libdave's persistent signing key storage. Nil uses an ephemeral key per session.
+	PersistentKeys *PersistentKeyConfig
+	// StrictMode makes Encrypt return godave.ErrNotReady instead of passing frames through unencrypted
+	// while the session has no established E2EE epoch.
+	StrictMode bool
+	// Clock returns the current time used for event timestamps.
+	Clock func() time.Time
+	// MaxProtocolVersion caps the DAVE protocol version advertised and accepted by the session.
+	// Zero uses the maximum protocol version supported by libdave.
+	MaxProtocolVersion uint16
+	// SkipVersionCheck disables checking the loaded libdave with libdave.CheckVersion.
+	SkipVersionCheck bool
+}
+
+// PersistentKeyConfig configures libdave's persistent signing key storage.
+// Sessions created with the same PersistentKeyConfig present the same identity across reconnects and restarts,
+// which keeps pairwise fingerprints stable for users who verified them.
+type PersistentKeyConfig struct {
+	// Context identifies the key store libdave persists the signing keys in.
+	Context string
+	// AuthSessionID identifies the persisted signing key within the key store.
+	AuthSessionID string
+}
+
+// Validate reports whether the Config contains incompatible settings.
+func (c *Config) Validate() error {
+	if err := libdave.Available(); err != nil {
+		return err
+	}
+	if c.PersistentKeys != nil && c.PersistentKeys.AuthSessionID == "" {
+		return ErrMissingAuthSessionID
+	}
+	if c.Clock == nil {
+		return ErrMissingClock
+	}
+	if !c.SkipVersionCheck {
+		if err := libdave.CheckVersion(); err != nil {
+			return err
+		}
+	}
+	if maxVersion := libdave.MaxSupportedProtocolVersion(); c.MaxProtocolVersion > maxVersion {
+		return fmt.Errorf("%w: %d exceeds maximum version %d", ErrUnsupportedProtocolVersion, c.MaxProtocolVersion, maxVersion)
+	}
+	return nil
+}
+
+// ConfigOpt is a type alias for a function that takes a Config and is used to configure your sessions.
+type ConfigOpt func(config *Config)
+
+// Apply applies the given ConfigOpt(s) to the Config.
+func (c *Config) Apply(opts []ConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithSecurityEventHandler sets the godave.SecurityEventHandler called for every godave.SecurityEvent.
+func WithSecurityEventHandler(handler godave.SecurityEventHandler) ConfigOpt {
+	return func(config *Config) {
+		config.SecurityEventHandler = handler
+	}
+}
+
+// WithAdmissionPolicy sets the godave.AdmissionPolicy deciding which users are recognized as members of the MLS group.
+func WithAdmissionPolicy(policy godave.AdmissionPolicy) ConfigOpt {
+	return func(config *Config) {
+		config.AdmissionPolicy = policy
+	}
+}
+
+// WithRosterUpdateHandler sets the godave.RosterUpdateHandler called for every change of the MLS group roster.
+func WithRosterUpdateHandler(handler godave.RosterUpdateHandler) ConfigOpt {
+	return func(config *Config) {
+		config.RosterUpdateHandler = handler
+	}
+}
+
+// WithPersistentKeys enables libdave's persistent signing key storage using the given key store context and auth session ID.
+func WithPersistentKeys(context string, authSessionID string) ConfigOpt {
+	return func(config *Config) {
+		config.PersistentKeys = &PersistentKeyConfig{
+			Context:       context,
+			AuthSessionID: authSessionID,
+		}
+	}
+}
+
+// WithStrictMode makes Encrypt return godave.ErrNotReady instead of passing frames through unencrypted
+// while the session has no established E2EE epoch.
+func WithStrictMode(strict bool) ConfigOpt {
+	return func(config *Config) {
+		config.StrictMode = strict
+	}
+}
+
+// WithClock sets the function returning the current time used for event timestamps.
+func WithClock(clock func() time.Time) ConfigOpt {
+	return func(config *Config) {
+		config.Clock = clock
+	}
+}
+
+// WithMaxProtocolVersion caps the DAVE protocol version advertised and accepted by the session.
+// Zero uses the maximum protocol version supported by libdave.
+func WithMaxProtocolVersion(version uint16) ConfigOpt {
+	return func(config *Config) {
+		config.MaxProtocolVersion = version
+	}
+}
+
+// WithSkipVersionCheck disables checking the loaded libdave with libdave.CheckVersion.
+// This is only useful with custom libdave builds.
+func WithSkipVersionCheck(skip bool) ConfigOpt {
+	return func(config *Config) {
+		config.SkipVersionCheck = skip
+	}
+}
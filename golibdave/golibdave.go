@@ -3,8 +3,10 @@ package golibdave
 import (
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/disgoorg/godave"
+	"github.com/disgoorg/godave/davelog"
 	"github.com/disgoorg/godave/libdave"
 )
 
@@ -14,26 +16,83 @@ const (
 	mlsNewGroupExpectedEpoch = 1
 )
 
+func init() {
+	godave.Register(godave.Implementation{
+		Name:     "golibdave",
+		Priority: 100,
+		Create:   NewSession,
+		Available: func() error {
+			if err := libdave.Available(); err != nil {
+				return err
+			}
+			return libdave.CheckVersion()
+		},
+	})
+}
+
+// versionCheck logs a libdave version mismatch once for sessions created with NewSession.
+var versionCheck sync.Once
+
 var (
 	_ godave.SessionCreateFunc = NewSession
 	_ godave.Session           = (*session)(nil)
+	_ godave.RosterProvider    = (*session)(nil)
+	_ godave.VersionProvider   = (*session)(nil)
+	_ godave.BatchSession      = (*session)(nil)
+	_ godave.AppendSession     = (*session)(nil)
 )
 
-// NewSession returns a new DAVE session using libdave.
+// NewSession returns a new DAVE session using libdave with the DefaultConfig.
+// An incompatible libdave is logged on the first call, use NewSessionCreateFunc to fail instead.
+// If libdave is not available, the returned session fails every Encrypt and Decrypt with an error
+// wrapping libdave.ErrUnavailable.
 func NewSession(logger *slog.Logger, selfUserID godave.UserID, callbacks godave.Callbacks) godave.Session {
-	encryptor := libdave.NewEncryptor()
-	// Start in Passthrough by default
-	encryptor.SetPassthroughMode(true)
+	if err := libdave.Available(); err != nil {
+		logger.Error("libdave is not available, DAVE sessions will not work", slog.Any("err", err))
+		return &unavailableSession{err: err}
+	}
+
+	versionCheck.Do(func() {
+		if err := libdave.CheckVersion(); err != nil {
+			logger.Error("incompatible libdave installation", slog.Any("err", err))
+		}
+	})
+
+	return newSession(logger, selfUserID, callbacks, DefaultConfig())
+}
+
+// NewSessionCreateFunc returns a godave.SessionCreateFunc creating libdave sessions configured with the given ConfigOpt(s).
+// It returns an error if the resulting Config is invalid.
+func NewSessionCreateFunc(opts ...ConfigOpt) (godave.SessionCreateFunc, error) {
+	config := DefaultConfig()
+	config.Apply(opts)
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
+	return func(logger *slog.Logger, selfUserID godave.UserID, callbacks godave.Callbacks) godave.Session {
+		return newSession(logger, selfUserID, callbacks, config)
+	}, nil
+}
+
+func newSession(logger *slog.Logger, selfUserID godave.UserID, callbacks godave.Callbacks, config *Config) *session {
+	return newSessionWithBackend(logger, selfUserID, callbacks, config, newLibdaveBackend(config))
+}
+
+func newSessionWithBackend(logger *slog.Logger, selfUserID godave.UserID, callbacks godave.Callbacks, config *Config, backend backend) *session {
 	return &session{
-		selfUserID: selfUserID,
-		callbacks:  callbacks,
-		logger:     logger,
-		// Context and authSessionID are only used with persistent key storage and can be ignored most of the time
-		session:             libdave.NewSession("", ""),
-		encryptor:           encryptor,
-		decryptors:          make(map[godave.UserID]*libdave.Decryptor),
+		selfUserID:          selfUserID,
+		callbacks:           callbacks,
+		logger:              logger,
+		config:              config,
+		maxProtocolVersion:  backend.maxProtocolVersion,
+		session:             backend.session,
+		encryptor:           backend.encryptor,
+		newDecryptor:        backend.newDecryptor,
+		decryptors:          make(map[godave.UserID]decryptor),
 		preparedTransitions: make(map[uint16]uint16),
+		roster:              godave.Roster{},
 	}
 }
 
@@ -42,16 +101,40 @@ type session struct {
 	channelID                     godave.ChannelID
 	logger                        *slog.Logger
 	callbacks                     godave.Callbacks
-	session                       *libdave.Session
-	encryptor                     *libdave.Encryptor
+	config                        *Config
+	maxProtocolVersion            uint16
+	session                       mlsSession
+	encryptor                     encryptor
+	newDecryptor                  func() decryptor
 	decryptorsMu                  sync.RWMutex
-	decryptors                    map[godave.UserID]*libdave.Decryptor
+	decryptors                    map[godave.UserID]decryptor
 	preparedTransitions           map[uint16]uint16
 	lastPreparedTransitionVersion uint16
+
+	rosterMu sync.RWMutex
+	roster   godave.Roster
+
+	// establishedAt is when the current E2EE epoch was established, zero while in passthrough
+	establishedAt      time.Time
+	establishedVersion uint16
 }
 
 func (s *session) MaxSupportedProtocolVersion() int {
-	return int(libdave.MaxSupportedProtocolVersion())
+	return int(s.maxSupportedProtocolVersion())
+}
+
+func (s *session) maxSupportedProtocolVersion() uint16 {
+	if s.config.MaxProtocolVersion > disabledProtocolVersion && s.config.MaxProtocolVersion < s.maxProtocolVersion {
+		return s.config.MaxProtocolVersion
+	}
+	return s.maxProtocolVersion
+}
+
+func (s *session) ProtocolVersion() int {
+	if !s.Ready() {
+		return disabledProtocolVersion
+	}
+	return int(s.encryptor.GetProtocolVersion())
 }
 
 func (s *session) Ready() bool {
@@ -77,6 +160,10 @@ func (s *session) MaxEncryptedFrameSize(frameSize int) int {
 }
 
 func (s *session) Encrypt(ssrc uint32, frame []byte, encryptedFrame []byte) (int, error) {
+	if s.config.StrictMode && !s.Ready() {
+		return 0, godave.ErrNotReady
+	}
+
 	return s.encryptor.Encrypt(libdave.MediaTypeAudio, ssrc, frame, encryptedFrame)
 }
 
@@ -101,27 +188,73 @@ func (s *session) Decrypt(userID godave.UserID, frame []byte, decryptedFrame []b
 	}
 
 	// assume passthrough
-	return copy(frame, decryptedFrame), nil
+	return copy(decryptedFrame, frame), nil
+}
+
+func (s *session) EncryptAppend(dst []byte, ssrc uint32, frame []byte) ([]byte, error) {
+	if s.config.StrictMode && !s.Ready() {
+		return dst, godave.ErrNotReady
+	}
+
+	return s.encryptor.EncryptAppend(dst, libdave.MediaTypeAudio, ssrc, frame)
+}
+
+func (s *session) DecryptAppend(dst []byte, userID godave.UserID, frame []byte) ([]byte, error) {
+	s.decryptorsMu.RLock()
+	decryptor, ok := s.decryptors[userID]
+	s.decryptorsMu.RUnlock()
+	if ok {
+		return decryptor.DecryptAppend(dst, libdave.MediaTypeAudio, frame)
+	}
+
+	// assume passthrough
+	return append(dst, frame...), nil
 }
 
 func (s *session) AddUser(userID godave.UserID) {
+	s.logger.Debug("adding user", slog.String("user_id", string(userID)))
+	if !s.admitted(userID) {
+		s.denyAdmission(userID, initTransitionId)
+		return
+	}
+
 	s.decryptorsMu.Lock()
-	s.decryptors[userID] = libdave.NewDecryptor()
+	s.decryptors[userID] = s.newDecryptor()
 	s.decryptorsMu.Unlock()
 	s.setupKeyRatchetForUser(userID, s.lastPreparedTransitionVersion)
 }
 
 func (s *session) RemoveUser(userID godave.UserID) {
+	s.logger.Debug("removing user", slog.String("user_id", string(userID)))
 	s.decryptorsMu.Lock()
 	delete(s.decryptors, userID)
 	s.decryptorsMu.Unlock()
 }
 
 func (s *session) OnSelectProtocolAck(protocolVersion uint16) {
+	s.logger.Debug("received select protocol ack", slog.Int("protocol_version", int(protocolVersion)))
+	if s.rejectProtocolVersion(initTransitionId, protocolVersion) {
+		s.protocolInit(s.maxSupportedProtocolVersion())
+		return
+	}
+
 	s.protocolInit(protocolVersion)
 }
 
 func (s *session) OnDavePrepareTransition(transitionID uint16, protocolVersion uint16) {
+	s.logger.Debug("received prepare transition",
+		slog.Int("transition_id", int(transitionID)),
+		slog.Int("protocol_version", int(protocolVersion)),
+	)
+	if s.rejectProtocolVersion(transitionID, protocolVersion) {
+		if transitionID == initTransitionId {
+			s.protocolInit(s.maxSupportedProtocolVersion())
+		} else {
+			s.sendInvalidCommitWelcome(transitionID)
+		}
+		return
+	}
+
 	s.prepareTransition(transitionID, protocolVersion)
 
 	if transitionID != initTransitionId {
@@ -130,10 +263,20 @@ func (s *session) OnDavePrepareTransition(transitionID uint16, protocolVersion u
 }
 
 func (s *session) OnDaveExecuteTransition(transitionID uint16) {
+	s.logger.Debug("received execute transition", slog.Int("transition_id", int(transitionID)))
 	s.executeTransition(transitionID)
 }
 
 func (s *session) OnDavePrepareEpoch(epoch int, protocolVersion uint16) {
+	s.logger.Debug("received prepare epoch",
+		slog.Int("epoch", epoch),
+		slog.Int("protocol_version", int(protocolVersion)),
+	)
+	if s.rejectProtocolVersion(initTransitionId, protocolVersion) {
+		s.protocolInit(s.maxSupportedProtocolVersion())
+		return
+	}
+
 	s.prepareEpoch(epoch, protocolVersion)
 
 	if epoch == mlsNewGroupExpectedEpoch {
@@ -142,10 +285,12 @@ func (s *session) OnDavePrepareEpoch(epoch int, protocolVersion uint16) {
 }
 
 func (s *session) OnDaveMLSExternalSenderPackage(externalSenderPackage []byte) {
+	s.logger.Debug("received MLS external sender package", slog.Any("external_sender", davelog.ExternalSender(externalSenderPackage)))
 	s.session.SetExternalSender(externalSenderPackage)
 }
 
 func (s *session) OnDaveMLSProposals(proposals []byte) {
+	s.logger.Debug("received MLS proposals", slog.Any("proposals", davelog.Proposals(proposals)))
 	commitWelcome := s.session.ProcessProposals(proposals, s.recognizedUserIDs())
 
 	if commitWelcome != nil {
@@ -155,6 +300,12 @@ func (s *session) OnDaveMLSProposals(proposals []byte) {
 
 func (s *session) OnDaveMLSPrepareCommitTransition(transitionID uint16, commitMessage []byte) {
 	res := s.session.ProcessCommit(commitMessage)
+	s.logger.Debug("processed MLS commit",
+		slog.Int("transition_id", int(transitionID)),
+		slog.Any("commit", davelog.Commit(commitMessage)),
+		slog.Bool("ignored", res.IsIgnored()),
+		slog.Bool("failed", res.IsFailed()),
+	)
 
 	if res.IsIgnored() {
 		return
@@ -162,10 +313,20 @@ func (s *session) OnDaveMLSPrepareCommitTransition(transitionID uint16, commitMe
 
 	if res.IsFailed() {
 		s.sendInvalidCommitWelcome(transitionID)
+		s.downgrade(godave.DowngradeReasonCommitFailure, transitionID)
+		s.protocolInit(s.session.GetProtocolVersion())
+		return
+	}
+
+	changes := rosterOf(res)
+	if !s.admitCommit(transitionID, changes) {
+		s.sendInvalidCommitWelcome(transitionID)
+		s.downgrade(godave.DowngradeReasonAdmissionDenied, transitionID)
 		s.protocolInit(s.session.GetProtocolVersion())
 		return
 	}
 
+	s.applyRosterChanges(transitionID, changes)
 	s.prepareTransition(transitionID, s.session.GetProtocolVersion())
 	if transitionID != initTransitionId {
 		s.sendReadyForTransition(transitionID)
@@ -174,13 +335,20 @@ func (s *session) OnDaveMLSPrepareCommitTransition(transitionID uint16, commitMe
 
 func (s *session) OnDaveMLSWelcome(transitionID uint16, welcomeMessage []byte) {
 	res := s.session.ProcessWelcome(welcomeMessage, s.recognizedUserIDs())
+	s.logger.Debug("processed MLS welcome",
+		slog.Int("transition_id", int(transitionID)),
+		slog.Any("welcome", davelog.Welcome(welcomeMessage)),
+		slog.Bool("failed", res == nil),
+	)
 
 	if res == nil {
 		s.sendInvalidCommitWelcome(transitionID)
+		s.downgrade(godave.DowngradeReasonWelcomeFailure, transitionID)
 		s.sendMLSKeyPackage()
 		return
 	}
 
+	s.updateRoster(transitionID, rosterOf(res))
 	s.prepareTransition(transitionID, s.session.GetProtocolVersion())
 	if transitionID != initTransitionId {
 		s.sendReadyForTransition(transitionID)
@@ -193,12 +361,46 @@ func (s *session) recognizedUserIDs() []string {
 	userIDs = append(userIDs, string(s.selfUserID))
 
 	for userID := range s.decryptors {
-		userIDs = append(userIDs, string(userID))
+		if s.admitted(userID) {
+			userIDs = append(userIDs, string(userID))
+		}
 	}
 
 	return userIDs
 }
 
+func (s *session) admitted(userID godave.UserID) bool {
+	return userID == s.selfUserID || s.config.AdmissionPolicy == nil || s.config.AdmissionPolicy(userID)
+}
+
+// admitCommit checks all users added or updated by a commit against the godave.AdmissionPolicy.
+// Removed users are reported with an empty signature and are always accepted.
+func (s *session) admitCommit(transitionID uint16, changes godave.Roster) bool {
+	ok := true
+	for userID, key := range changes {
+		if len(key) == 0 || s.admitted(userID) {
+			continue
+		}
+
+		s.denyAdmission(userID, transitionID)
+		ok = false
+	}
+
+	return ok
+}
+
+func (s *session) denyAdmission(userID godave.UserID, transitionID uint16) {
+	s.logger.Warn("user rejected by admission policy",
+		slog.String("user_id", string(userID)),
+		slog.Int("transition_id", int(transitionID)),
+	)
+	s.emitSecurityEvent(&godave.AdmissionDeniedEvent{
+		UserID:       userID,
+		TransitionID: transitionID,
+		DetectedAt:   s.config.Clock(),
+	})
+}
+
 func (s *session) protocolInit(protocolVersion uint16) {
 	if protocolVersion > disabledProtocolVersion {
 		s.prepareEpoch(mlsNewGroupExpectedEpoch, protocolVersion)
@@ -214,7 +416,36 @@ func (s *session) prepareEpoch(epoch int, protocolVersion uint16) {
 		return
 	}
 
+	s.logger.Debug("creating MLS group",
+		slog.Int("protocol_version", int(protocolVersion)),
+		slog.Uint64("channel_id", uint64(s.channelID)),
+	)
 	s.session.Init(protocolVersion, uint64(s.channelID), string(s.selfUserID))
+	s.updateRoster(initTransitionId, nil)
+}
+
+// rejectProtocolVersion reports whether the protocol version exceeds MaxSupportedProtocolVersion.
+// The caller responds to the voice gateway, the protocol version of commits and welcomes is checked when
+// it is announced by select protocol ack, prepare transition or prepare epoch.
+func (s *session) rejectProtocolVersion(transitionID uint16, protocolVersion uint16) bool {
+	maxVersion := s.maxSupportedProtocolVersion()
+	if protocolVersion <= maxVersion {
+		return false
+	}
+
+	s.logger.Error("rejecting unsupported protocol version",
+		slog.Int("transition_id", int(transitionID)),
+		slog.Int("protocol_version", int(protocolVersion)),
+		slog.Int("max_protocol_version", int(maxVersion)),
+	)
+	s.emitSecurityEvent(&godave.ProtocolVersionRejectedEvent{
+		TransitionID:       transitionID,
+		ProtocolVersion:    protocolVersion,
+		MaxProtocolVersion: maxVersion,
+		DetectedAt:         s.config.Clock(),
+	})
+	s.downgrade(godave.DowngradeReasonProtocolVersionRejected, transitionID)
+	return true
 }
 
 func (s *session) executeTransition(transitionID uint16) {
@@ -224,12 +455,18 @@ func (s *session) executeTransition(transitionID uint16) {
 	}
 
 	delete(s.preparedTransitions, transitionID)
+	s.logger.Debug("executing transition",
+		slog.Int("transition_id", int(transitionID)),
+		slog.Int("protocol_version", int(protocolVersion)),
+	)
 
 	if protocolVersion == disabledProtocolVersion {
 		s.session.Reset()
+		s.updateRoster(transitionID, nil)
 	}
 
 	s.setupKeyRatchetForUser(s.selfUserID, protocolVersion)
+	s.updateEncryptionState(transitionID, protocolVersion)
 }
 
 func (s *session) prepareTransition(transitionID uint16, protocolVersion uint16) {
@@ -239,9 +476,14 @@ func (s *session) prepareTransition(transitionID uint16, protocolVersion uint16)
 
 	if transitionID == initTransitionId {
 		s.setupKeyRatchetForUser(s.selfUserID, protocolVersion)
+		s.updateEncryptionState(transitionID, protocolVersion)
 	} else {
 		s.preparedTransitions[transitionID] = protocolVersion
 	}
+	s.logger.Debug("prepared transition",
+		slog.Int("transition_id", int(transitionID)),
+		slog.Int("protocol_version", int(protocolVersion)),
+	)
 
 	s.lastPreparedTransitionVersion = protocolVersion
 }
@@ -264,25 +506,78 @@ func (s *session) setupKeyRatchetForUser(userID godave.UserID, protocolVersion u
 	}
 }
 
+// updateEncryptionState tracks the E2EE epoch of our own encryptor after a transition was applied
+func (s *session) updateEncryptionState(transitionID uint16, protocolVersion uint16) {
+	if protocolVersion == disabledProtocolVersion {
+		s.downgrade(godave.DowngradeReasonTransition, transitionID)
+		return
+	}
+
+	if s.establishedAt.IsZero() {
+		s.establishedAt = s.config.Clock()
+		s.logger.Debug("end-to-end encryption established",
+			slog.Int("transition_id", int(transitionID)),
+			slog.Int("protocol_version", int(protocolVersion)),
+		)
+	}
+	s.establishedVersion = protocolVersion
+}
+
+// downgrade emits a godave.DowngradeEvent if the session had an established E2EE epoch
+func (s *session) downgrade(reason godave.DowngradeReason, transitionID uint16) {
+	if s.establishedAt.IsZero() {
+		return
+	}
+
+	event := &godave.DowngradeEvent{
+		Reason:          reason,
+		TransitionID:    transitionID,
+		ProtocolVersion: s.establishedVersion,
+		EstablishedAt:   s.establishedAt,
+		DetectedAt:      s.config.Clock(),
+	}
+	s.establishedAt = time.Time{}
+	s.establishedVersion = disabledProtocolVersion
+
+	s.logger.Warn("end-to-end encryption downgraded",
+		slog.String("reason", reason.String()),
+		slog.Int("transition_id", int(transitionID)),
+		slog.Int("protocol_version", int(event.ProtocolVersion)),
+		slog.Time("established_at", event.EstablishedAt),
+	)
+	s.emitSecurityEvent(event)
+}
+
+func (s *session) emitSecurityEvent(event godave.SecurityEvent) {
+	if s.config.SecurityEventHandler != nil {
+		s.config.SecurityEventHandler(event)
+	}
+}
+
 func (s *session) sendMLSKeyPackage() {
-	if err := s.callbacks.SendMLSKeyPackage(s.session.GetMarshalledKeyPackage()); err != nil {
+	keyPackage := s.session.GetMarshalledKeyPackage()
+	s.logger.Debug("sending MLS key package", slog.Any("key_package", davelog.KeyPackage(keyPackage)))
+	if err := s.callbacks.SendMLSKeyPackage(keyPackage); err != nil {
 		s.logger.Error("failed to send MLS key package", slog.Any("err", err))
 	}
 }
 
 func (s *session) sendMLSCommitWelcome(message []byte) {
+	s.logger.Debug("sending MLS commit welcome", slog.Any("commit_welcome", davelog.CommitWelcome(message)))
 	if err := s.callbacks.SendMLSCommitWelcome(message); err != nil {
 		s.logger.Error("failed to send MLS commit welcome", slog.Any("err", err))
 	}
 }
 
 func (s *session) sendReadyForTransition(transitionID uint16) {
+	s.logger.Debug("sending ready for transition", slog.Int("transition_id", int(transitionID)))
 	if err := s.callbacks.SendReadyForTransition(transitionID); err != nil {
 		s.logger.Error("failed to send ready for transition", slog.Any("err", err))
 	}
 }
 
 func (s *session) sendInvalidCommitWelcome(transitionID uint16) {
+	s.logger.Debug("sending invalid commit welcome", slog.Int("transition_id", int(transitionID)))
 	if err := s.callbacks.SendInvalidCommitWelcome(transitionID); err != nil {
 		s.logger.Error("failed to send invalid commit welcome", slog.Any("err", err))
 	}
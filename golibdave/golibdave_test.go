@@ -0,0 +1,518 @@
+package golibdave
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/godave"
+	"github.com/disgoorg/godave/libdave"
+)
+
+const (
+	selfUserID       godave.UserID = "1"
+	testVersion                    = 1
+	testTransitionID               = 5
+)
+
+var testTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeResult is a commit or welcome result reporting roster changes.
+type fakeResult struct {
+	roster  map[uint64][]byte
+	failed  bool
+	ignored bool
+}
+
+func (r *fakeResult) GetRosterMemberIDs() []uint64 {
+	rosterIDs := make([]uint64, 0, len(r.roster))
+	for rosterID := range r.roster {
+		rosterIDs = append(rosterIDs, rosterID)
+	}
+	return rosterIDs
+}
+func (r *fakeResult) GetRosterMemberSignature(rosterID uint64) []byte { return r.roster[rosterID] }
+func (r *fakeResult) IsFailed() bool                                  { return r.failed }
+func (r *fakeResult) IsIgnored() bool                                 { return r.ignored }
+
+// fakeMLSSession returns the configured commit and welcome results and records the protocol version.
+type fakeMLSSession struct {
+	protocolVersion uint16
+	commit          *fakeResult
+	welcome         *fakeResult
+}
+
+func (s *fakeMLSSession) Init(version uint16, _ uint64, _ string) {
+	s.protocolVersion = version
+}
+func (s *fakeMLSSession) Reset()                                       { s.protocolVersion = disabledProtocolVersion }
+func (s *fakeMLSSession) GetProtocolVersion() uint16                   { return s.protocolVersion }
+func (s *fakeMLSSession) SetExternalSender(_ []byte)                   {}
+func (s *fakeMLSSession) ProcessProposals(_ []byte, _ []string) []byte { return nil }
+func (s *fakeMLSSession) ProcessCommit(_ []byte) commitResult          { return s.commit }
+func (s *fakeMLSSession) ProcessWelcome(_ []byte, _ []string) rosterResult {
+	if s.welcome == nil {
+		return nil
+	}
+	return s.welcome
+}
+func (s *fakeMLSSession) GetMarshalledKeyPackage() []byte { return []byte("key package") }
+func (s *fakeMLSSession) GetKeyRatchet(_ string) *libdave.KeyRatchet {
+	return &libdave.KeyRatchet{}
+}
+
+// fakeEncryptor passes frames through and tracks the passthrough mode and key ratchet.
+type fakeEncryptor struct {
+	passthrough bool
+	keyRatchet  *libdave.KeyRatchet
+}
+
+func (e *fakeEncryptor) HasKeyRatchet() bool                                     { return e.keyRatchet != nil }
+func (e *fakeEncryptor) IsPassthroughMode() bool                                 { return e.passthrough }
+func (e *fakeEncryptor) SetKeyRatchet(keyRatchet *libdave.KeyRatchet)            { e.keyRatchet = keyRatchet }
+func (e *fakeEncryptor) SetPassthroughMode(passthroughMode bool)                 { e.passthrough = passthroughMode }
+func (e *fakeEncryptor) AssignSsrcToCodec(_ uint32, _ libdave.Codec)             {}
+func (e *fakeEncryptor) GetProtocolVersion() uint16                              { return testVersion }
+func (e *fakeEncryptor) GetMaxCiphertextByteSize(_ libdave.MediaType, n int) int { return n }
+func (e *fakeEncryptor) Encrypt(_ libdave.MediaType, _ uint32, frame []byte, encryptedFrame []byte) (int, error) {
+	return copy(encryptedFrame, frame), nil
+}
+func (e *fakeEncryptor) EncryptAppend(dst []byte, _ libdave.MediaType, _ uint32, frame []byte) ([]byte, error) {
+	return append(dst, frame...), nil
+}
+func (e *fakeEncryptor) EncryptBatch(frames []libdave.EncryptFrame) {
+	for i := range frames {
+		frames[i].N, frames[i].Err = e.Encrypt(frames[i].MediaType, frames[i].SSRC, frames[i].Frame, frames[i].EncryptedFrame)
+	}
+}
+
+type fakeDecryptor struct{}
+
+func (d *fakeDecryptor) TransitionToKeyRatchet(_ *libdave.KeyRatchet)           {}
+func (d *fakeDecryptor) TransitionToPassthroughMode(_ bool)                     {}
+func (d *fakeDecryptor) GetMaxPlaintextByteSize(_ libdave.MediaType, n int) int { return n }
+func (d *fakeDecryptor) Decrypt(_ libdave.MediaType, frame []byte, decryptedFrame []byte) (int, error) {
+	return copy(decryptedFrame, frame), nil
+}
+func (d *fakeDecryptor) DecryptAppend(dst []byte, _ libdave.MediaType, frame []byte) ([]byte, error) {
+	return append(dst, frame...), nil
+}
+
+// fakeCallbacks records the messages sent to the voice gateway.
+type fakeCallbacks struct {
+	keyPackages        int
+	readyTransitions   []uint16
+	invalidTransitions []uint16
+}
+
+func (c *fakeCallbacks) SendMLSKeyPackage(_ []byte) error    { c.keyPackages++; return nil }
+func (c *fakeCallbacks) SendMLSCommitWelcome(_ []byte) error { return nil }
+func (c *fakeCallbacks) SendReadyForTransition(transitionID uint16) error {
+	c.readyTransitions = append(c.readyTransitions, transitionID)
+	return nil
+}
+func (c *fakeCallbacks) SendInvalidCommitWelcome(transitionID uint16) error {
+	c.invalidTransitions = append(c.invalidTransitions, transitionID)
+	return nil
+}
+
+type testSession struct {
+	*session
+	mls        *fakeMLSSession
+	encryptor  *fakeEncryptor
+	callbacks  *fakeCallbacks
+	events     []godave.SecurityEvent
+	updates    []godave.RosterUpdate
+	decryptors int
+}
+
+func newTestSession(t *testing.T, opts ...ConfigOpt) *testSession {
+	t.Helper()
+	ts := &testSession{
+		mls:       &fakeMLSSession{},
+		encryptor: &fakeEncryptor{passthrough: true},
+		callbacks: &fakeCallbacks{},
+	}
+
+	config := DefaultConfig()
+	config.Clock = func() time.Time { return testTime }
+	config.SecurityEventHandler = func(event godave.SecurityEvent) { ts.events = append(ts.events, event) }
+	config.RosterUpdateHandler = func(update godave.RosterUpdate) { ts.updates = append(ts.updates, update) }
+	config.Apply(opts)
+
+	ts.session = newSessionWithBackend(slog.New(slog.DiscardHandler), selfUserID, ts.callbacks, config, backend{
+		maxProtocolVersion: testVersion,
+		session:            ts.mls,
+		encryptor:          ts.encryptor,
+		newDecryptor: func() decryptor {
+			ts.decryptors++
+			return &fakeDecryptor{}
+		},
+	})
+	return ts
+}
+
+// establish creates the MLS group and joins it with a welcome containing the given roster.
+func (ts *testSession) establish(t *testing.T, roster map[uint64][]byte) {
+	t.Helper()
+	ts.OnSelectProtocolAck(testVersion)
+	ts.mls.welcome = &fakeResult{roster: roster}
+	ts.OnDaveMLSWelcome(testTransitionID, []byte("welcome"))
+	ts.OnDaveExecuteTransition(testTransitionID)
+	if !ts.Ready() {
+		t.Fatal("expected session to be ready")
+	}
+}
+
+func TestEstablish(t *testing.T) {
+	ts := newTestSession(t)
+	ts.establish(t, map[uint64][]byte{1: []byte("self"), 2: []byte("key")})
+
+	if ts.callbacks.keyPackages != 1 {
+		t.Errorf("expected 1 key package, got %d", ts.callbacks.keyPackages)
+	}
+	if !reflect.DeepEqual(ts.callbacks.readyTransitions, []uint16{testTransitionID}) {
+		t.Errorf("expected ready for transition %d, got %v", testTransitionID, ts.callbacks.readyTransitions)
+	}
+	if ts.ProtocolVersion() != testVersion {
+		t.Errorf("expected protocol version %d, got %d", testVersion, ts.ProtocolVersion())
+	}
+	if len(ts.events) != 0 {
+		t.Errorf("expected no security events, got %v", ts.events)
+	}
+}
+
+func TestDecryptPassthrough(t *testing.T) {
+	ts := newTestSession(t)
+	frame := []byte("frame")
+
+	// frames of users without a decryptor are copied into decryptedFrame up to its length, like the noop session
+	buf := bytes.Repeat([]byte{0xff}, 2*len(frame))
+	n, err := ts.Decrypt("2", frame, buf[:len(frame)])
+	if err != nil || !bytes.Equal(buf[:n], frame) {
+		t.Errorf("Decrypt: expected %q, got %q, %v", frame, buf[:n], err)
+	}
+	if string(frame) != "frame" {
+		t.Errorf("Decrypt: expected frame to be unchanged, got %q", frame)
+	}
+	if !bytes.Equal(buf[len(frame):], bytes.Repeat([]byte{0xff}, len(frame))) {
+		t.Errorf("Decrypt: expected no writes beyond len(decryptedFrame), got %x", buf)
+	}
+
+	buf = bytes.Repeat([]byte{0xff}, 2*len(frame))
+	frames := []godave.DecryptFrame{{UserID: "2", Frame: frame, DecryptedFrame: buf[:len(frame)]}}
+	ts.DecryptBatch(frames)
+	if frames[0].Err != nil || !bytes.Equal(buf[:frames[0].N], frame) {
+		t.Errorf("DecryptBatch: expected %q, got %q, %v", frame, buf[:frames[0].N], frames[0].Err)
+	}
+	if !bytes.Equal(buf[len(frame):], bytes.Repeat([]byte{0xff}, len(frame))) {
+		t.Errorf("DecryptBatch: expected no writes beyond len(decryptedFrame), got %x", buf)
+	}
+}
+
+func TestAdmissionDenied(t *testing.T) {
+	ts := newTestSession(t, WithAdmissionPolicy(func(userID godave.UserID) bool {
+		return userID == "2"
+	}))
+	ts.AddUser("2")
+	ts.AddUser("3")
+
+	want := []godave.SecurityEvent{&godave.AdmissionDeniedEvent{
+		UserID:       "3",
+		TransitionID: initTransitionId,
+		DetectedAt:   testTime,
+	}}
+	if !reflect.DeepEqual(ts.events, want) {
+		t.Errorf("expected %+v, got %+v", want, ts.events)
+	}
+	if ts.decryptors != 1 {
+		t.Errorf("expected a decryptor only for the admitted user, got %d decryptors", ts.decryptors)
+	}
+	if userIDs := ts.recognizedUserIDs(); len(userIDs) != 2 {
+		t.Errorf("expected self and the admitted user to be recognized, got %v", userIDs)
+	}
+}
+
+func TestDowngrade(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		transitionID uint16
+		downgrade    func(ts *testSession)
+		reason       godave.DowngradeReason
+		invalid      bool
+	}{
+		{
+			name:         "transition",
+			transitionID: 6,
+			downgrade: func(ts *testSession) {
+				ts.OnDavePrepareTransition(6, disabledProtocolVersion)
+				ts.OnDaveExecuteTransition(6)
+			},
+			reason: godave.DowngradeReasonTransition,
+		},
+		{
+			name:         "commit failure",
+			transitionID: 6,
+			downgrade: func(ts *testSession) {
+				ts.mls.commit = &fakeResult{failed: true}
+				ts.OnDaveMLSPrepareCommitTransition(6, []byte("commit"))
+			},
+			reason:  godave.DowngradeReasonCommitFailure,
+			invalid: true,
+		},
+		{
+			name:         "welcome failure",
+			transitionID: 6,
+			downgrade: func(ts *testSession) {
+				ts.mls.welcome = nil
+				ts.OnDaveMLSWelcome(6, []byte("welcome"))
+			},
+			reason:  godave.DowngradeReasonWelcomeFailure,
+			invalid: true,
+		},
+		{
+			name:         "admission denied",
+			transitionID: 6,
+			downgrade: func(ts *testSession) {
+				ts.mls.commit = &fakeResult{roster: map[uint64][]byte{3: []byte("key")}}
+				ts.OnDaveMLSPrepareCommitTransition(6, []byte("commit"))
+			},
+			reason:  godave.DowngradeReasonAdmissionDenied,
+			invalid: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestSession(t, WithAdmissionPolicy(func(userID godave.UserID) bool {
+				return userID != "3"
+			}))
+			ts.establish(t, map[uint64][]byte{1: []byte("self")})
+
+			tt.downgrade(ts)
+
+			var downgrade *godave.DowngradeEvent
+			for _, event := range ts.events {
+				if e, ok := event.(*godave.DowngradeEvent); ok {
+					if downgrade != nil {
+						t.Fatalf("expected one downgrade event, got %v", ts.events)
+					}
+					downgrade = e
+				}
+			}
+			want := &godave.DowngradeEvent{
+				Reason:          tt.reason,
+				TransitionID:    tt.transitionID,
+				ProtocolVersion: testVersion,
+				EstablishedAt:   testTime,
+				DetectedAt:      testTime,
+			}
+			if !reflect.DeepEqual(downgrade, want) {
+				t.Errorf("expected %+v, got %+v", want, downgrade)
+			}
+
+			var invalid []uint16
+			if tt.invalid {
+				invalid = []uint16{tt.transitionID}
+			}
+			if !reflect.DeepEqual(ts.callbacks.invalidTransitions, invalid) {
+				t.Errorf("expected invalid commit welcome for %v, got %v", invalid, ts.callbacks.invalidTransitions)
+			}
+		})
+	}
+}
+
+func TestDowngradeNotEstablished(t *testing.T) {
+	ts := newTestSession(t)
+	ts.OnSelectProtocolAck(testVersion)
+	ts.mls.commit = &fakeResult{failed: true}
+	ts.OnDaveMLSPrepareCommitTransition(testTransitionID, []byte("commit"))
+
+	if len(ts.events) != 0 {
+		t.Errorf("expected no security events before E2EE was established, got %v", ts.events)
+	}
+	if !reflect.DeepEqual(ts.callbacks.invalidTransitions, []uint16{testTransitionID}) {
+		t.Errorf("expected invalid commit welcome for %d, got %v", testTransitionID, ts.callbacks.invalidTransitions)
+	}
+}
+
+func TestRosterUpdates(t *testing.T) {
+	ts := newTestSession(t)
+	ts.establish(t, map[uint64][]byte{1: []byte("self"), 2: []byte("old")})
+
+	ts.mls.commit = &fakeResult{roster: map[uint64][]byte{
+		2: []byte("new"),
+		3: []byte("key"),
+	}}
+	ts.OnDaveMLSPrepareCommitTransition(6, []byte("commit"))
+
+	ts.mls.commit = &fakeResult{roster: map[uint64][]byte{3: nil}}
+	ts.OnDaveMLSPrepareCommitTransition(7, []byte("commit"))
+
+	want := []godave.RosterUpdate{
+		{
+			TransitionID: testTransitionID,
+			Added:        godave.Roster{"1": []byte("self"), "2": []byte("old")},
+			Removed:      godave.Roster{},
+			Changed:      map[godave.UserID]godave.RosterKeyChange{},
+			UpdatedAt:    testTime,
+		},
+		{
+			TransitionID: 6,
+			Added:        godave.Roster{"3": []byte("key")},
+			Removed:      godave.Roster{},
+			Changed:      map[godave.UserID]godave.RosterKeyChange{"2": {Old: []byte("old"), New: []byte("new")}},
+			UpdatedAt:    testTime,
+		},
+		{
+			TransitionID: 7,
+			Added:        godave.Roster{},
+			Removed:      godave.Roster{"3": []byte("key")},
+			Changed:      map[godave.UserID]godave.RosterKeyChange{},
+			UpdatedAt:    testTime,
+		},
+	}
+	if !reflect.DeepEqual(ts.updates, want) {
+		t.Errorf("expected %+v, got %+v", want, ts.updates)
+	}
+
+	roster := godave.Roster{"1": []byte("self"), "2": []byte("new")}
+	if !reflect.DeepEqual(ts.Roster(), roster) {
+		t.Errorf("expected %v, got %v", roster, ts.Roster())
+	}
+
+	// the transition to protocol version 0 resets the MLS group and empties the roster
+	ts.OnDavePrepareTransition(8, disabledProtocolVersion)
+	ts.OnDaveExecuteTransition(8)
+	if len(ts.Roster()) != 0 {
+		t.Errorf("expected an empty roster, got %v", ts.Roster())
+	}
+	if last := ts.updates[len(ts.updates)-1]; last.TransitionID != 8 || !reflect.DeepEqual(last.Removed, roster) {
+		t.Errorf("expected transition 8 to remove %v, got %+v", roster, last)
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		t.Run(strconv.FormatBool(strict), func(t *testing.T) {
+			ts := newTestSession(t, WithStrictMode(strict))
+			frame := []byte("frame")
+			encryptedFrame := make([]byte, len(frame))
+
+			var wantErr error
+			if strict {
+				wantErr = godave.ErrNotReady
+			}
+			if _, err := ts.Encrypt(0, frame, encryptedFrame); !errors.Is(err, wantErr) {
+				t.Errorf("Encrypt: expected %v, got %v", wantErr, err)
+			}
+			if _, err := ts.EncryptAppend(nil, 0, frame); !errors.Is(err, wantErr) {
+				t.Errorf("EncryptAppend: expected %v, got %v", wantErr, err)
+			}
+			frames := []godave.EncryptFrame{{Frame: frame, EncryptedFrame: encryptedFrame}}
+			ts.EncryptBatch(frames)
+			if !errors.Is(frames[0].Err, wantErr) {
+				t.Errorf("EncryptBatch: expected %v, got %v", wantErr, frames[0].Err)
+			}
+
+			ts.establish(t, map[uint64][]byte{1: []byte("self")})
+			n, err := ts.Encrypt(0, frame, encryptedFrame)
+			if err != nil || !bytes.Equal(encryptedFrame[:n], frame) {
+				t.Errorf("expected frame to be encrypted once ready, got %q, %v", encryptedFrame[:n], err)
+			}
+		})
+	}
+}
+
+func TestRejectProtocolVersion(t *testing.T) {
+	const rejectedVersion = testVersion + 1
+
+	for _, tt := range []struct {
+		name               string
+		established        bool
+		transitionID       uint16
+		reject             func(ts *testSession)
+		invalidTransitions []uint16
+		keyPackages        int
+	}{
+		{
+			name: "select protocol ack",
+			reject: func(ts *testSession) {
+				ts.OnSelectProtocolAck(rejectedVersion)
+			},
+			// the MLS group is created with the maximum supported protocol version
+			keyPackages: 1,
+		},
+		{
+			name:         "prepare transition",
+			established:  true,
+			transitionID: 6,
+			reject: func(ts *testSession) {
+				ts.OnDavePrepareTransition(6, rejectedVersion)
+			},
+			invalidTransitions: []uint16{6},
+			keyPackages:        1,
+		},
+		{
+			name:        "prepare init transition",
+			established: true,
+			reject: func(ts *testSession) {
+				ts.OnDavePrepareTransition(initTransitionId, rejectedVersion)
+			},
+			keyPackages: 2,
+		},
+		{
+			name:        "prepare epoch",
+			established: true,
+			reject: func(ts *testSession) {
+				ts.OnDavePrepareEpoch(mlsNewGroupExpectedEpoch, rejectedVersion)
+			},
+			keyPackages: 2,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// libdave supports the rejected version, but the session is capped below it
+			ts := newTestSession(t, WithMaxProtocolVersion(testVersion))
+			ts.maxProtocolVersion = rejectedVersion
+			if tt.established {
+				ts.establish(t, map[uint64][]byte{1: []byte("self")})
+			}
+			readyTransitions := len(ts.callbacks.readyTransitions)
+
+			tt.reject(ts)
+
+			want := []godave.SecurityEvent{&godave.ProtocolVersionRejectedEvent{
+				TransitionID:       tt.transitionID,
+				ProtocolVersion:    rejectedVersion,
+				MaxProtocolVersion: testVersion,
+				DetectedAt:         testTime,
+			}}
+			if tt.established {
+				want = append(want, &godave.DowngradeEvent{
+					Reason:          godave.DowngradeReasonProtocolVersionRejected,
+					TransitionID:    tt.transitionID,
+					ProtocolVersion: testVersion,
+					EstablishedAt:   testTime,
+					DetectedAt:      testTime,
+				})
+			}
+			if !reflect.DeepEqual(ts.events, want) {
+				t.Errorf("expected %+v, got %+v", want, ts.events)
+			}
+			if !reflect.DeepEqual(ts.callbacks.invalidTransitions, tt.invalidTransitions) {
+				t.Errorf("expected invalid commit welcome for %v, got %v", tt.invalidTransitions, ts.callbacks.invalidTransitions)
+			}
+			if len(ts.callbacks.readyTransitions) != readyTransitions {
+				t.Errorf("expected no ready for transition, got %v", ts.callbacks.readyTransitions[readyTransitions:])
+			}
+			if ts.callbacks.keyPackages != tt.keyPackages {
+				t.Errorf("expected %d key packages, got %d", tt.keyPackages, ts.callbacks.keyPackages)
+			}
+			if ts.mls.protocolVersion > testVersion {
+				t.Errorf("expected the MLS group to stay at or below protocol version %d, got %d", testVersion, ts.mls.protocolVersion)
+			}
+		})
+	}
+}
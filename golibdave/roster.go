@@ -0,0 +1,81 @@
+package golibdave
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/disgoorg/godave"
+)
+
+// rosterResult is implemented by libdave.CommitResult and libdave.WelcomeResult.
+type rosterResult interface {
+	GetRosterMemberIDs() []uint64
+	GetRosterMemberSignature(rosterID uint64) []byte
+}
+
+// rosterOf returns the roster reported by a commit or welcome.
+// For commits libdave only reports the changed members, removed members have an empty signature key.
+func rosterOf(res rosterResult) godave.Roster {
+	rosterIDs := res.GetRosterMemberIDs()
+
+	roster := make(godave.Roster, len(rosterIDs))
+	for _, rosterID := range rosterIDs {
+		roster[godave.UserID(strconv.FormatUint(rosterID, 10))] = res.GetRosterMemberSignature(rosterID)
+	}
+
+	return roster
+}
+
+func (s *session) Roster() godave.Roster {
+	s.rosterMu.RLock()
+	defer s.rosterMu.RUnlock()
+
+	return s.roster.Clone()
+}
+
+// applyRosterChanges applies the roster changes reported by a commit.
+func (s *session) applyRosterChanges(transitionID uint16, changes godave.Roster) {
+	s.rosterMu.RLock()
+	roster := s.roster.Clone()
+	s.rosterMu.RUnlock()
+
+	for userID, key := range changes {
+		if len(key) == 0 {
+			delete(roster, userID)
+			continue
+		}
+		roster[userID] = key
+	}
+
+	s.updateRoster(transitionID, roster)
+}
+
+// updateRoster replaces the roster and notifies the godave.RosterUpdateHandler about the changes.
+func (s *session) updateRoster(transitionID uint16, roster godave.Roster) {
+	if roster == nil {
+		roster = godave.Roster{}
+	}
+
+	s.rosterMu.Lock()
+	update := s.roster.Diff(roster)
+	s.roster = roster
+	s.rosterMu.Unlock()
+
+	if update.Empty() {
+		return
+	}
+
+	update.TransitionID = transitionID
+	update.UpdatedAt = s.config.Clock()
+
+	s.logger.Debug("MLS roster updated",
+		slog.Int("transition_id", int(transitionID)),
+		slog.Int("added", len(update.Added)),
+		slog.Int("removed", len(update.Removed)),
+		slog.Int("changed", len(update.Changed)),
+	)
+
+	if s.config.RosterUpdateHandler != nil {
+		s.config.RosterUpdateHandler(update)
+	}
+}
@@ -0,0 +1,47 @@
+package golibdave
+
+import (
+	"github.com/disgoorg/godave"
+)
+
+var _ godave.Session = (*unavailableSession)(nil)
+
+// unavailableSession is returned by NewSession if libdave can not be used.
+// It never becomes ready and fails every Encrypt and Decrypt with the error returned by libdave.Available.
+type unavailableSession struct {
+	err error
+}
+
+func (s *unavailableSession) MaxSupportedProtocolVersion() int {
+	return disabledProtocolVersion
+}
+func (s *unavailableSession) Ready() bool {
+	return false
+}
+func (s *unavailableSession) Close() error {
+	return nil
+}
+func (s *unavailableSession) MaxEncryptedFrameSize(frameSize int) int {
+	return frameSize
+}
+func (s *unavailableSession) Encrypt(_ uint32, _ []byte, _ []byte) (int, error) {
+	return 0, s.err
+}
+func (s *unavailableSession) MaxDecryptedFrameSize(_ godave.UserID, frameSize int) int {
+	return frameSize
+}
+func (s *unavailableSession) Decrypt(_ godave.UserID, _ []byte, _ []byte) (int, error) {
+	return 0, s.err
+}
+func (s *unavailableSession) SetChannelID(_ godave.ChannelID)                     {}
+func (s *unavailableSession) AssignSsrcToCodec(_ uint32, _ godave.Codec)          {}
+func (s *unavailableSession) AddUser(_ godave.UserID)                             {}
+func (s *unavailableSession) RemoveUser(_ godave.UserID)                          {}
+func (s *unavailableSession) OnSelectProtocolAck(_ uint16)                        {}
+func (s *unavailableSession) OnDavePrepareTransition(_ uint16, _ uint16)          {}
+func (s *unavailableSession) OnDaveExecuteTransition(_ uint16)                    {}
+func (s *unavailableSession) OnDavePrepareEpoch(_ int, _ uint16)                  {}
+func (s *unavailableSession) OnDaveMLSExternalSenderPackage(_ []byte)             {}
+func (s *unavailableSession) OnDaveMLSProposals(_ []byte)                         {}
+func (s *unavailableSession) OnDaveMLSPrepareCommitTransition(_ uint16, _ []byte) {}
+func (s *unavailableSession) OnDaveMLSWelcome(_ uint16, _ []byte)                 {}
@@ -0,0 +1,29 @@
+package libdave
+
+import (
+	"slices"
+)
+
+// EncryptAppend encrypts frame and appends the encrypted frame to dst, growing dst as needed.
+// It returns dst unchanged along with the error if the frame could not be encrypted.
+// With cgo, EncryptAppend does not allocate once dst has enough spare capacity.
+func (e *Encryptor) EncryptAppend(dst []byte, mediaType MediaType, ssrc uint32, frame []byte) ([]byte, error) {
+	dst = slices.Grow(dst, e.GetMaxCiphertextByteSize(mediaType, len(frame)))
+	n, err := e.Encrypt(mediaType, ssrc, frame, dst[len(dst):cap(dst)])
+	if err != nil {
+		return dst, err
+	}
+	return dst[:len(dst)+n], nil
+}
+
+// DecryptAppend decrypts frame and appends the decrypted frame to dst, growing dst as needed.
+// It returns dst unchanged along with the error if the frame could not be decrypted.
+// With cgo, DecryptAppend does not allocate once dst has enough spare capacity.
+func (d *Decryptor) DecryptAppend(dst []byte, mediaType MediaType, frame []byte) ([]byte, error) {
+	dst = slices.Grow(dst, d.GetMaxPlaintextByteSize(mediaType, len(frame)))
+	n, err := d.Decrypt(mediaType, frame, dst[len(dst):cap(dst)])
+	if err != nil {
+		return dst, err
+	}
+	return dst[:len(dst)+n], nil
+}
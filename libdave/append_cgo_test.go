@@ -0,0 +1,38 @@
+//go:build !libdave_purego
+
+package libdave
+
+import (
+	"testing"
+)
+
+// Calls through purego allocate, so only the cgo bindings encrypt and decrypt without allocating.
+
+func TestEncryptAppendAllocs(t *testing.T) {
+	skipUnavailable(t)
+	encryptor := newPassthroughEncryptor(1)
+	frame := make([]byte, opusFrameSize)
+	dst := make([]byte, 0, encryptor.GetMaxCiphertextByteSize(MediaTypeAudio, opusFrameSize))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dst, _ = encryptor.EncryptAppend(dst[:0], MediaTypeAudio, 0, frame)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations per frame, got %v", allocs)
+	}
+}
+
+func TestDecryptAppendAllocs(t *testing.T) {
+	skipUnavailable(t)
+	decryptor := NewDecryptor()
+	decryptor.TransitionToPassthroughMode(true)
+	frame := make([]byte, opusFrameSize)
+	dst := make([]byte, 0, decryptor.GetMaxPlaintextByteSize(MediaTypeAudio, opusFrameSize))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dst, _ = decryptor.DecryptAppend(dst[:0], MediaTypeAudio, frame)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations per frame, got %v", allocs)
+	}
+}
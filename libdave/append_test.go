@@ -0,0 +1,63 @@
+package libdave
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptAppend(t *testing.T) {
+	skipUnavailable(t)
+	encryptor := newPassthroughEncryptor(1)
+	frame := bytes.Repeat([]byte{1}, opusFrameSize)
+
+	dst, err := encryptor.EncryptAppend([]byte("prefix"), MediaTypeAudio, 0, frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(dst, []byte("prefix")) {
+		t.Errorf("expected dst to keep its prefix, got %q", dst[:6])
+	}
+	if !bytes.Equal(dst[6:], frame) {
+		t.Errorf("expected passthrough frame to be appended")
+	}
+}
+
+func TestDecryptAppend(t *testing.T) {
+	skipUnavailable(t)
+	decryptor := NewDecryptor()
+	decryptor.TransitionToPassthroughMode(true)
+	frame := bytes.Repeat([]byte{1}, opusFrameSize)
+
+	dst, err := decryptor.DecryptAppend(nil, MediaTypeAudio, frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst, frame) {
+		t.Errorf("expected passthrough frame to be appended")
+	}
+}
+
+func BenchmarkEncryptAppend(b *testing.B) {
+	skipUnavailable(b)
+	encryptor := newPassthroughEncryptor(1)
+	frame := make([]byte, opusFrameSize)
+	var dst []byte
+
+	b.ReportAllocs()
+	for b.Loop() {
+		dst, _ = encryptor.EncryptAppend(dst[:0], MediaTypeAudio, 0, frame)
+	}
+}
+
+func BenchmarkDecryptAppend(b *testing.B) {
+	skipUnavailable(b)
+	decryptor := NewDecryptor()
+	decryptor.TransitionToPassthroughMode(true)
+	frame := make([]byte, opusFrameSize)
+	var dst []byte
+
+	b.ReportAllocs()
+	for b.Loop() {
+		dst, _ = decryptor.DecryptAppend(dst[:0], MediaTypeAudio, frame)
+	}
+}
@@ -0,0 +1,35 @@
+package libdave
+
+// EncryptFrame is a frame encrypted by Encryptor.EncryptBatch.
+type EncryptFrame struct {
+	// MediaType is the media type of the frame.
+	MediaType MediaType
+	// SSRC is the SSRC the frame is sent with.
+	SSRC uint32
+	// Frame is the frame to encrypt.
+	Frame []byte
+	// EncryptedFrame receives the encrypted frame. It is written up to its capacity.
+	EncryptedFrame []byte
+
+	// N is set to the number of bytes written to EncryptedFrame.
+	N int
+	// Err is set to the error encrypting the frame, if any.
+	Err error
+}
+
+// DecryptFrame is a frame decrypted by DecryptBatch.
+type DecryptFrame struct {
+	// Decryptor is the Decryptor of the user who sent the frame.
+	Decryptor *Decryptor
+	// MediaType is the media type of the frame.
+	MediaType MediaType
+	// Frame is the frame to decrypt.
+	Frame []byte
+	// DecryptedFrame receives the decrypted frame. It is written up to its capacity.
+	DecryptedFrame []byte
+
+	// N is set to the number of bytes written to DecryptedFrame.
+	N int
+	// Err is set to the error decrypting the frame, if any.
+	Err error
+}
@@ -0,0 +1,150 @@
+//go:build !libdave_purego
+
+package libdave
+
+// #include "dave.h"
+//
+// typedef struct {
+// 	DAVEMediaType mediaType;
+// 	uint32_t ssrc;
+// 	size_t frameOffset;
+// 	size_t frameLength;
+// 	size_t encryptedFrameOffset;
+// 	size_t encryptedFrameCapacity;
+// 	size_t bytesWritten;
+// 	DAVEEncryptorResultCode result;
+// } godaveEncryptFrame;
+//
+// typedef struct {
+// 	DAVEDecryptorHandle decryptor;
+// 	DAVEMediaType mediaType;
+// 	size_t frameOffset;
+// 	size_t frameLength;
+// 	size_t decryptedFrameOffset;
+// 	size_t decryptedFrameCapacity;
+// 	size_t bytesWritten;
+// 	DAVEDecryptorResultCode result;
+// } godaveDecryptFrame;
+//
+// static void godaveEncryptorEncryptBatch(DAVEEncryptorHandle encryptor, const uint8_t* in, uint8_t* out, godaveEncryptFrame* frames, size_t count) {
+// 	for (size_t i = 0; i < count; i++) {
+// 		godaveEncryptFrame* f = &frames[i];
+// 		f->result = daveEncryptorEncrypt(encryptor, f->mediaType, f->ssrc, in + f->frameOffset, f->frameLength, out + f->encryptedFrameOffset, f->encryptedFrameCapacity, &f->bytesWritten);
+// 	}
+// }
+//
+// static void godaveDecryptorDecryptBatch(const uint8_t* in, uint8_t* out, godaveDecryptFrame* frames, size_t count) {
+// 	for (size_t i = 0; i < count; i++) {
+// 		godaveDecryptFrame* f = &frames[i];
+// 		f->result = daveDecryptorDecrypt(f->decryptor, f->mediaType, in + f->frameOffset, f->frameLength, out + f->decryptedFrameOffset, f->decryptedFrameCapacity, &f->bytesWritten);
+// 	}
+// }
+import "C"
+import (
+	"runtime"
+	"sync"
+)
+
+// batchBuffers holds the memory passed to libdave by a batch call.
+// Frames are copied into contiguous buffers and addressed by offset, which is cheaper than pinning every frame.
+type batchBuffers struct {
+	in            []byte
+	out           []byte
+	encryptFrames []C.godaveEncryptFrame
+	decryptFrames []C.godaveDecryptFrame
+}
+
+var batchBuffersPool = sync.Pool{
+	New: func() any {
+		return &batchBuffers{}
+	},
+}
+
+// EncryptBatch encrypts all frames with a single cgo call and sets their N and Err fields.
+func (e *Encryptor) EncryptBatch(frames []EncryptFrame) {
+	if len(frames) == 0 {
+		return
+	}
+
+	buffers := batchBuffersPool.Get().(*batchBuffers)
+	defer batchBuffersPool.Put(buffers)
+
+	cFrames := buffers.encryptFrames[:0]
+	in, out := buffers.in[:0], 0
+	for _, frame := range frames {
+		cFrames = append(cFrames, C.godaveEncryptFrame{
+			mediaType:              C.DAVEMediaType(frame.MediaType),
+			ssrc:                   C.uint32_t(frame.SSRC),
+			frameOffset:            C.size_t(len(in)),
+			frameLength:            C.size_t(len(frame.Frame)),
+			encryptedFrameOffset:   C.size_t(out),
+			encryptedFrameCapacity: C.size_t(cap(frame.EncryptedFrame)),
+		})
+		in = append(in, frame.Frame...)
+		out += cap(frame.EncryptedFrame)
+	}
+	buffers.encryptFrames, buffers.in, buffers.out = cFrames, in, grow(buffers.out, out)
+
+	C.godaveEncryptorEncryptBatch(e.handle, bytesPtr(buffers.in), bytesPtr(buffers.out), &cFrames[0], C.size_t(len(cFrames)))
+
+	for i := range frames {
+		frame, cFrame := &frames[i], &cFrames[i]
+		frame.N = int(cFrame.bytesWritten)
+		frame.Err = encryptorResultCode(cFrame.result).ToError()
+		copy(frame.EncryptedFrame[:frame.N], buffers.out[cFrame.encryptedFrameOffset:])
+	}
+	runtime.KeepAlive(e)
+}
+
+// DecryptBatch decrypts all frames with their Decryptor using a single cgo call and sets their N and Err fields.
+// The frames may belong to different decryptors.
+func DecryptBatch(frames []DecryptFrame) {
+	if len(frames) == 0 {
+		return
+	}
+
+	buffers := batchBuffersPool.Get().(*batchBuffers)
+	defer batchBuffersPool.Put(buffers)
+
+	cFrames := buffers.decryptFrames[:0]
+	in, out := buffers.in[:0], 0
+	for _, frame := range frames {
+		cFrames = append(cFrames, C.godaveDecryptFrame{
+			decryptor:              frame.Decryptor.handle,
+			mediaType:              C.DAVEMediaType(frame.MediaType),
+			frameOffset:            C.size_t(len(in)),
+			frameLength:            C.size_t(len(frame.Frame)),
+			decryptedFrameOffset:   C.size_t(out),
+			decryptedFrameCapacity: C.size_t(cap(frame.DecryptedFrame)),
+		})
+		in = append(in, frame.Frame...)
+		out += cap(frame.DecryptedFrame)
+	}
+	buffers.decryptFrames, buffers.in, buffers.out = cFrames, in, grow(buffers.out, out)
+
+	C.godaveDecryptorDecryptBatch(bytesPtr(buffers.in), bytesPtr(buffers.out), &cFrames[0], C.size_t(len(cFrames)))
+
+	for i := range frames {
+		frame, cFrame := &frames[i], &cFrames[i]
+		frame.N = int(cFrame.bytesWritten)
+		frame.Err = decryptorResultCode(cFrame.result).ToError()
+		copy(frame.DecryptedFrame[:frame.N], buffers.out[cFrame.decryptedFrameOffset:])
+	}
+	// keep the decryptors from being finalized while libdave uses them
+	runtime.KeepAlive(frames)
+}
+
+// grow returns b resized to n bytes, reusing its backing array if possible.
+func grow(b []byte, n int) []byte {
+	if cap(b) < n {
+		return make([]byte, n)
+	}
+	return b[:n]
+}
+
+func bytesPtr(b []byte) *C.uint8_t {
+	if cap(b) == 0 {
+		return nil
+	}
+	return (*C.uint8_t)(&b[:1][0])
+}
@@ -0,0 +1,21 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+// EncryptBatch encrypts all frames and sets their N and Err fields.
+// Calls into libdave loaded with purego are not batched, each frame is encrypted with a separate call.
+func (e *Encryptor) EncryptBatch(frames []EncryptFrame) {
+	for i := range frames {
+		frame := &frames[i]
+		frame.N, frame.Err = e.Encrypt(frame.MediaType, frame.SSRC, frame.Frame, frame.EncryptedFrame)
+	}
+}
+
+// DecryptBatch decrypts all frames with their Decryptor and sets their N and Err fields.
+// Calls into libdave loaded with purego are not batched, each frame is decrypted with a separate call.
+func DecryptBatch(frames []DecryptFrame) {
+	for i := range frames {
+		frame := &frames[i]
+		frame.N, frame.Err = frame.Decryptor.Decrypt(frame.MediaType, frame.Frame, frame.DecryptedFrame)
+	}
+}
@@ -0,0 +1,141 @@
+package libdave
+
+import (
+	"strconv"
+	"testing"
+)
+
+// opusFrameSize is the size of a typical 20ms Opus frame.
+const opusFrameSize = 160
+
+var batchSizes = []int{1, 16, 128}
+
+func newPassthroughEncryptor(ssrcs int) *Encryptor {
+	encryptor := NewEncryptor()
+	encryptor.SetPassthroughMode(true)
+	for ssrc := range ssrcs {
+		encryptor.AssignSsrcToCodec(uint32(ssrc), CodecOpus)
+	}
+	return encryptor
+}
+
+func newEncryptFrames(encryptor *Encryptor, n int) []EncryptFrame {
+	frames := make([]EncryptFrame, n)
+	for i := range frames {
+		frames[i] = EncryptFrame{
+			MediaType:      MediaTypeAudio,
+			SSRC:           uint32(i),
+			Frame:          make([]byte, opusFrameSize),
+			EncryptedFrame: make([]byte, encryptor.GetMaxCiphertextByteSize(MediaTypeAudio, opusFrameSize)),
+		}
+	}
+	return frames
+}
+
+func newDecryptFrames(n int) []DecryptFrame {
+	frames := make([]DecryptFrame, n)
+	for i := range frames {
+		decryptor := NewDecryptor()
+		decryptor.TransitionToPassthroughMode(true)
+		frames[i] = DecryptFrame{
+			Decryptor:      decryptor,
+			MediaType:      MediaTypeAudio,
+			Frame:          make([]byte, opusFrameSize),
+			DecryptedFrame: make([]byte, decryptor.GetMaxPlaintextByteSize(MediaTypeAudio, opusFrameSize)),
+		}
+	}
+	return frames
+}
+
+func TestEncryptBatch(t *testing.T) {
+	skipUnavailable(t)
+	encryptor := newPassthroughEncryptor(4)
+	frames := newEncryptFrames(encryptor, 4)
+	for i := range frames {
+		frames[i].Frame[0] = byte(i + 1)
+	}
+
+	encryptor.EncryptBatch(frames)
+
+	for i, frame := range frames {
+		if frame.Err != nil {
+			t.Fatalf("frame %d: %v", i, frame.Err)
+		}
+		n, err := encryptor.Encrypt(frame.MediaType, frame.SSRC, frame.Frame, make([]byte, cap(frame.EncryptedFrame)))
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if frame.N != n {
+			t.Errorf("frame %d: expected %d bytes like Encrypt, got %d", i, n, frame.N)
+		}
+	}
+}
+
+// BenchmarkEncrypt and BenchmarkEncryptBatch report the cost per frame, the difference is the cgo overhead amortized
+// by batching. The encryptors are in passthrough mode, so the numbers exclude the cost of encryption. The benchmarks
+// package measures Encrypt with an established E2EE epoch.
+func BenchmarkEncrypt(b *testing.B) {
+	skipUnavailable(b)
+	for _, size := range batchSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			encryptor := newPassthroughEncryptor(size)
+			frames := newEncryptFrames(encryptor, size)
+			b.ReportAllocs()
+			for b.Loop() {
+				for i := range frames {
+					frame := &frames[i]
+					frame.N, frame.Err = encryptor.Encrypt(frame.MediaType, frame.SSRC, frame.Frame, frame.EncryptedFrame)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/frame")
+		})
+	}
+}
+
+func BenchmarkEncryptBatch(b *testing.B) {
+	skipUnavailable(b)
+	for _, size := range batchSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			encryptor := newPassthroughEncryptor(size)
+			frames := newEncryptFrames(encryptor, size)
+			b.ReportAllocs()
+			for b.Loop() {
+				encryptor.EncryptBatch(frames)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/frame")
+		})
+	}
+}
+
+// BenchmarkDecrypt and BenchmarkDecryptBatch report the cost per frame of passthrough decryptors like BenchmarkEncrypt,
+// the numbers exclude the cost of decryption.
+func BenchmarkDecrypt(b *testing.B) {
+	skipUnavailable(b)
+	for _, size := range batchSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			frames := newDecryptFrames(size)
+			b.ReportAllocs()
+			for b.Loop() {
+				for i := range frames {
+					frame := &frames[i]
+					frame.N, frame.Err = frame.Decryptor.Decrypt(frame.MediaType, frame.Frame, frame.DecryptedFrame)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/frame")
+		})
+	}
+}
+
+func BenchmarkDecryptBatch(b *testing.B) {
+	skipUnavailable(b)
+	for _, size := range batchSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			frames := newDecryptFrames(size)
+			b.ReportAllocs()
+			for b.Loop() {
+				DecryptBatch(frames)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size), "ns/frame")
+		})
+	}
+}
@@ -0,0 +1,91 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+	"weak"
+
+	"github.com/ebitengine/purego"
+)
+
+// purego callbacks can never be released, so each callback is only created once.
+var (
+	logSinkCallback                uintptr
+	failureCallback                uintptr
+	pairwiseFingerprintCallback    uintptr
+	protocolVersionChangedCallback uintptr
+)
+
+func registerCallbacks() {
+	logSinkCallback = purego.NewCallback(godaveGlobalLogCallback)
+	failureCallback = purego.NewCallback(godaveGlobalFailureCallback)
+	pairwiseFingerprintCallback = purego.NewCallback(godavePairwiseFingerprintCallback)
+	protocolVersionChangedCallback = purego.NewCallback(godaveProtocolVersionChangedCallback)
+}
+
+func godaveGlobalLogCallback(severity int32, file *byte, line int32, message *byte) {
+	logMessage(logSeverity(severity), goString(file), int(line), goString(message))
+}
+
+func godaveGlobalFailureCallback(source *byte, reason *byte, userData uintptr) {
+	authSessionID := handle(userData).value().(string)
+
+	defaultLogger.Load().Error(
+		goString(reason),
+		slog.String("source", goString(source)),
+		slog.String("authSessionID", authSessionID),
+	)
+}
+
+func godavePairwiseFingerprintCallback(fingerprint *byte, length uintptr, userData uintptr) {
+	retChan := handle(userData).value().(chan []byte)
+
+	// Copy the data over into Go land
+	// No need to free the C array, as the library will do it for us
+	view := unsafe.Slice(fingerprint, length)
+	slice := make([]byte, length)
+	copy(slice, view)
+
+	retChan <- slice
+}
+
+func godaveProtocolVersionChangedCallback(userData uintptr) {
+	encryptor := handle(userData).value().(weak.Pointer[Encryptor]).Value()
+
+	if encryptor == nil {
+		return
+	}
+
+	defaultLogger.Load().Debug("protocol version changed", slog.Int("newVersion", int(encryptor.GetProtocolVersion())))
+}
+
+// handle is passed to libdave as callback user data and resolves to a Go value,
+// similar to runtime/cgo.Handle which is not available without cgo.
+type handle uintptr
+
+var (
+	handles   sync.Map
+	handleIdx atomic.Uintptr
+)
+
+func newHandle(v any) handle {
+	h := handle(handleIdx.Add(1))
+	handles.Store(h, v)
+	return h
+}
+
+func (h handle) value() any {
+	v, ok := handles.Load(h)
+	if !ok {
+		panic("libdave: misuse of an invalid handle")
+	}
+	return v
+}
+
+func (h handle) delete() {
+	handles.Delete(h)
+}
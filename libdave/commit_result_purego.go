@@ -0,0 +1,54 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+type commitResultHandle = uintptr
+
+type CommitResult struct {
+	handle commitResultHandle
+}
+
+func newCommitResult(handle commitResultHandle) *CommitResult {
+	commitResult := &CommitResult{
+		handle: handle,
+	}
+
+	runtime.SetFinalizer(commitResult, func(c *CommitResult) {
+		daveCommitResultDestroy(c.handle)
+	})
+
+	return commitResult
+}
+
+func (r *CommitResult) IsFailed() bool {
+	return daveCommitResultIsFailed(r.handle)
+}
+
+func (r *CommitResult) IsIgnored() bool {
+	return daveCommitResultIsIgnored(r.handle)
+}
+
+func (r *CommitResult) GetRosterMemberIDs() []uint64 {
+	var (
+		rosterIDs       unsafe.Pointer
+		rosterIDsLength uintptr
+	)
+	daveCommitResultGetRosterMemberIds(r.handle, &rosterIDs, &rosterIDsLength)
+
+	return newUint64Slice(rosterIDs, rosterIDsLength)
+}
+
+func (r *CommitResult) GetRosterMemberSignature(rosterID uint64) []byte {
+	var (
+		rosterMemberSignature       unsafe.Pointer
+		rosterMemberSignatureLength uintptr
+	)
+	daveCommitResultGetRosterMemberSignature(r.handle, rosterID, &rosterMemberSignature, &rosterMemberSignatureLength)
+
+	return newByteSlice(rosterMemberSignature, rosterMemberSignatureLength)
+}
@@ -1,3 +1,5 @@
+//go:build !libdave_purego
+
 package libdave
 
 // #include "dave.h"
@@ -7,41 +9,6 @@ import (
 	"unsafe"
 )
 
-type decryptorResultCode int
-
-const (
-	decryptorResultCodeSuccess decryptorResultCode = iota
-	decryptorResultCodeDecryptionFailure
-	decryptorResultCodeMissingKeyRatchet
-	decryptorResultCodeInvalidNonce
-	decryptorResultCodeMissingCryptor
-)
-
-func (r decryptorResultCode) ToError() error {
-	switch r {
-	case decryptorResultCodeSuccess:
-		return nil
-	case decryptorResultCodeMissingKeyRatchet:
-		return ErrMissingKeyRatchet
-	case decryptorResultCodeInvalidNonce:
-		return ErrInvalidNonce
-	case decryptorResultCodeMissingCryptor:
-		return ErrMissingCryptor
-	default:
-		return ErrGenericDecryptionFailure
-	}
-}
-
-type DecryptorStats struct {
-	PassthroughCount         uint64
-	DecryptSuccessCount      uint64
-	DecryptFailureCount      uint64
-	DecryptDuration          uint64
-	DecryptAttempts          uint64
-	DecryptMissingKeyCount   uint64
-	DecryptInvalidNonceCount uint64
-}
-
 type decryptorHandle = C.DAVEDecryptorHandle
 
 type Decryptor struct {
@@ -73,7 +40,10 @@ func (d *Decryptor) GetMaxPlaintextByteSize(mediaType MediaType, encryptedFrameS
 }
 
 func (d *Decryptor) Decrypt(mediaType MediaType, frame []byte, decryptedFrame []byte) (int, error) {
-	var bytesWritten C.size_t
+	bytesWritten := bytesWrittenPool.Get().(*C.size_t)
+	defer bytesWrittenPool.Put(bytesWritten)
+	*bytesWritten = 0
+
 	res := decryptorResultCode(C.daveDecryptorDecrypt(
 		d.handle,
 		C.DAVEMediaType(mediaType),
@@ -81,10 +51,10 @@ func (d *Decryptor) Decrypt(mediaType MediaType, frame []byte, decryptedFrame []
 		C.size_t(len(frame)),
 		(*C.uint8_t)(unsafe.Pointer(&decryptedFrame[0])),
 		C.size_t(cap(decryptedFrame)),
-		&bytesWritten,
+		bytesWritten,
 	))
 
-	return int(bytesWritten), res.ToError()
+	return int(*bytesWritten), res.ToError()
 }
 
 func (d *Decryptor) GetStats(mediaType MediaType) *DecryptorStats {
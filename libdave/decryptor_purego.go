@@ -0,0 +1,64 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"runtime"
+)
+
+type decryptorHandle = uintptr
+
+type Decryptor struct {
+	handle decryptorHandle
+}
+
+func NewDecryptor() *Decryptor {
+	mustLoad()
+
+	decryptor := &Decryptor{
+		handle: daveDecryptorCreate(),
+	}
+
+	runtime.SetFinalizer(decryptor, func(d *Decryptor) {
+		daveDecryptorDestroy(d.handle)
+	})
+
+	return decryptor
+}
+
+func (d *Decryptor) TransitionToKeyRatchet(keyRatchet *KeyRatchet) {
+	daveDecryptorTransitionToKeyRatchet(d.handle, keyRatchet.handle)
+}
+
+func (d *Decryptor) TransitionToPassthroughMode(passthroughMode bool) {
+	daveDecryptorTransitionToPassthroughMode(d.handle, passthroughMode)
+}
+
+func (d *Decryptor) GetMaxPlaintextByteSize(mediaType MediaType, encryptedFrameSize int) int {
+	return int(daveDecryptorGetMaxPlaintextByteSize(d.handle, int32(mediaType), uintptr(encryptedFrameSize)))
+}
+
+func (d *Decryptor) Decrypt(mediaType MediaType, frame []byte, decryptedFrame []byte) (int, error) {
+	bytesWritten := bytesWrittenPool.Get().(*uintptr)
+	defer bytesWrittenPool.Put(bytesWritten)
+	*bytesWritten = 0
+
+	res := decryptorResultCode(daveDecryptorDecrypt(
+		d.handle,
+		int32(mediaType),
+		&frame[0],
+		uintptr(len(frame)),
+		&decryptedFrame[0],
+		uintptr(cap(decryptedFrame)),
+		bytesWritten,
+	))
+
+	return int(*bytesWritten), res.ToError()
+}
+
+func (d *Decryptor) GetStats(mediaType MediaType) *DecryptorStats {
+	var stats DecryptorStats
+	daveDecryptorGetStats(d.handle, int32(mediaType), &stats)
+
+	return &stats
+}
@@ -1,3 +1,5 @@
+//go:build !libdave_purego
+
 package libdave
 
 // #include "dave.h"
@@ -11,31 +13,6 @@ import (
 	"weak"
 )
 
-type encryptorResultCode int
-
-const (
-	encryptorResultCodeSuccess encryptorResultCode = iota
-	encryptorResultCodeEncryptionFailure
-	encryptorResultCodeMissingKeyRatchet
-	encryptionResultCodeMissingCryptor
-	encryptionResultCodeTooManyAttempts
-)
-
-func (r encryptorResultCode) ToError() error {
-	switch r {
-	case encryptorResultCodeSuccess:
-		return nil
-	case encryptorResultCodeMissingKeyRatchet:
-		return ErrMissingKeyRatchet
-	case encryptionResultCodeMissingCryptor:
-		return ErrMissingCryptor
-	case encryptionResultCodeTooManyAttempts:
-		return ErrTooManyAttempts
-	default:
-		return ErrGenericEncryptionFailure
-	}
-}
-
 //export godaveProtocolVersionChangedCallback
 func godaveProtocolVersionChangedCallback(userData unsafe.Pointer) {
 	h := *(*cgo.Handle)(userData)
@@ -48,16 +25,6 @@ func godaveProtocolVersionChangedCallback(userData unsafe.Pointer) {
 	defaultLogger.Load().Debug("protocol version changed", slog.Int("newVersion", int(encryptor.GetProtocolVersion())))
 }
 
-type EncryptorStats struct {
-	PassthroughCount       uint64
-	EncryptSuccessCount    uint64
-	EncryptFailureCount    uint64
-	EncryptDuration        uint64
-	EncryptAttempts        uint64
-	EncryptMaxAttempts     uint64
-	EncryptMissingKeyCount uint64
-}
-
 type encryptionHandle = C.DAVEEncryptorHandle
 
 type Encryptor struct {
@@ -117,7 +84,10 @@ func (e *Encryptor) GetMaxCiphertextByteSize(mediaType MediaType, frameSize int)
 }
 
 func (e *Encryptor) Encrypt(mediaType MediaType, ssrc uint32, frame []byte, encryptedFrame []byte) (int, error) {
-	var bytesWritten C.size_t
+	bytesWritten := bytesWrittenPool.Get().(*C.size_t)
+	defer bytesWrittenPool.Put(bytesWritten)
+	*bytesWritten = 0
+
 	res := encryptorResultCode(C.daveEncryptorEncrypt(
 		e.handle,
 		C.DAVEMediaType(mediaType),
@@ -126,10 +96,10 @@ func (e *Encryptor) Encrypt(mediaType MediaType, ssrc uint32, frame []byte, encr
 		C.size_t(len(frame)),
 		(*C.uint8_t)(unsafe.Pointer(&encryptedFrame[0])),
 		C.size_t(cap(encryptedFrame)),
-		&bytesWritten,
+		bytesWritten,
 	))
 
-	return int(bytesWritten), res.ToError()
+	return int(*bytesWritten), res.ToError()
 }
 
 func (e *Encryptor) GetStats(mediaType MediaType) *EncryptorStats {
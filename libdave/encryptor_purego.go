@@ -0,0 +1,93 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"runtime"
+	"weak"
+)
+
+type encryptionHandle = uintptr
+
+type Encryptor struct {
+	handle    encryptionHandle
+	cgoHandle handle
+}
+
+func NewEncryptor() *Encryptor {
+	mustLoad()
+
+	encryptor := &Encryptor{
+		handle: daveEncryptorCreate(),
+	}
+
+	// A weak pointer is necessary here to avoid circular refs
+	encryptor.cgoHandle = newHandle(weak.Make(encryptor))
+
+	daveEncryptorSetProtocolVersionChangedCallback(
+		encryptor.handle,
+		protocolVersionChangedCallback,
+		uintptr(encryptor.cgoHandle),
+	)
+
+	runtime.SetFinalizer(encryptor, func(e *Encryptor) {
+		daveEncryptorDestroy(e.handle)
+		e.cgoHandle.delete()
+	})
+
+	return encryptor
+}
+
+func (e *Encryptor) HasKeyRatchet() bool {
+	return daveEncryptorHasKeyRatchet(e.handle)
+}
+
+func (e *Encryptor) IsPassthroughMode() bool {
+	return daveEncryptorIsPassthroughMode(e.handle)
+}
+
+func (e *Encryptor) SetKeyRatchet(keyRatchet *KeyRatchet) {
+	daveEncryptorSetKeyRatchet(e.handle, keyRatchet.handle)
+}
+
+func (e *Encryptor) SetPassthroughMode(passthroughMode bool) {
+	daveEncryptorSetPassthroughMode(e.handle, passthroughMode)
+}
+
+func (e *Encryptor) AssignSsrcToCodec(ssrc uint32, codec Codec) {
+	daveEncryptorAssignSsrcToCodec(e.handle, ssrc, int32(codec))
+}
+
+func (e *Encryptor) GetProtocolVersion() uint16 {
+	return daveEncryptorGetProtocolVersion(e.handle)
+}
+
+func (e *Encryptor) GetMaxCiphertextByteSize(mediaType MediaType, frameSize int) int {
+	return int(daveEncryptorGetMaxCiphertextByteSize(e.handle, int32(mediaType), uintptr(frameSize)))
+}
+
+func (e *Encryptor) Encrypt(mediaType MediaType, ssrc uint32, frame []byte, encryptedFrame []byte) (int, error) {
+	bytesWritten := bytesWrittenPool.Get().(*uintptr)
+	defer bytesWrittenPool.Put(bytesWritten)
+	*bytesWritten = 0
+
+	res := encryptorResultCode(daveEncryptorEncrypt(
+		e.handle,
+		int32(mediaType),
+		ssrc,
+		&frame[0],
+		uintptr(len(frame)),
+		&encryptedFrame[0],
+		uintptr(cap(encryptedFrame)),
+		bytesWritten,
+	))
+
+	return int(*bytesWritten), res.ToError()
+}
+
+func (e *Encryptor) GetStats(mediaType MediaType) *EncryptorStats {
+	var stats EncryptorStats
+	daveEncryptorGetStats(e.handle, int32(mediaType), &stats)
+
+	return &stats
+}
@@ -9,4 +9,5 @@ var (
 	ErrInvalidNonce             = errors.New("invalid nonce")
 	ErrMissingCryptor           = errors.New("missing cryptor")
 	ErrTooManyAttempts          = errors.New("too many attempts to encrypt the frame failed")
+	ErrUnavailable              = errors.New("libdave: not available")
 )
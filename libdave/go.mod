@@ -1,3 +1,5 @@
 module github.com/disgoorg/godave/libdave
 
 go 1.24.0
+
+require github.com/ebitengine/purego v0.10.0
@@ -0,0 +1,21 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import "runtime"
+
+type keyRatchetHandle = uintptr
+
+type KeyRatchet struct {
+	handle keyRatchetHandle
+}
+
+func newKeyRatchet(handle keyRatchetHandle) *KeyRatchet {
+	keyRatchet := &KeyRatchet{handle: handle}
+
+	runtime.SetFinalizer(keyRatchet, func(k *KeyRatchet) {
+		daveKeyRatchetDestroy(k.handle)
+	})
+
+	return keyRatchet
+}
@@ -1,12 +1,37 @@
+//go:build !libdave_purego
+
 package libdave
 
 // FIXME: Consider https://pkg.go.dev/cmd/cgo#hdr-Optimizing_calls_of_C_code
 
 // #cgo pkg-config: dave
 // #include "dave.h"
+//
+// // LIBDAVE_VERSION is defined by the dave.pc written by the libdave install scripts.
+// #define LIBDAVE_STRINGIFY(x) #x
+// #define LIBDAVE_VERSION_STRING(x) LIBDAVE_STRINGIFY(x)
+//
+// static const char *libdaveVersion(void) {
+// #ifdef LIBDAVE_VERSION
+//     return LIBDAVE_VERSION_STRING(LIBDAVE_VERSION);
+// #else
+//     return "";
+// #endif
+// }
 import "C"
 
 // MaxSupportedProtocolVersion returns the maximum supported libdave protocol version.
 func MaxSupportedProtocolVersion() uint16 {
 	return uint16(C.daveMaxSupportedProtocolVersion())
 }
+
+// Version returns the libdave release recorded in dave.pc by the libdave install scripts, or an empty string if the
+// linked libdave was installed without them.
+func Version() string {
+	return C.GoString(C.libdaveVersion())
+}
+
+// Available reports whether libdave can be used. Linking libdave with cgo makes it always available.
+func Available() error {
+	return nil
+}
@@ -0,0 +1,219 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+// LibraryPathEnv is the environment variable overriding the path libdave is loaded from.
+const LibraryPathEnv = "LIBDAVE_PATH"
+
+// loadErr is the error encountered loading libdave, if any.
+var loadErr error
+
+var (
+	free func(ptr unsafe.Pointer)
+
+	daveMaxSupportedProtocolVersion func() uint16
+	daveSetLogSinkCallback          func(callback uintptr)
+
+	daveSessionCreate                    func(context unsafe.Pointer, authSessionID string, callback uintptr, userData uintptr) sessionHandle
+	daveSessionDestroy                   func(session sessionHandle)
+	daveSessionInit                      func(session sessionHandle, version uint16, groupID uint64, selfUserID string)
+	daveSessionReset                     func(session sessionHandle)
+	daveSessionSetProtocolVersion        func(session sessionHandle, version uint16)
+	daveSessionGetProtocolVersion        func(session sessionHandle) uint16
+	daveSessionGetLastEpochAuthenticator func(session sessionHandle, authenticator *unsafe.Pointer, length *uintptr)
+	daveSessionSetExternalSender         func(session sessionHandle, externalSender *byte, length uintptr)
+	daveSessionProcessProposals          func(session sessionHandle, proposals *byte, length uintptr, recognizedUserIDs **byte, recognizedUserIDsLength uintptr, commitWelcome *unsafe.Pointer, commitWelcomeLength *uintptr)
+	daveSessionProcessCommit             func(session sessionHandle, commit *byte, length uintptr) commitResultHandle
+	daveSessionProcessWelcome            func(session sessionHandle, welcome *byte, length uintptr, recognizedUserIDs **byte, recognizedUserIDsLength uintptr) welcomeResultHandle
+	daveSessionGetMarshalledKeyPackage   func(session sessionHandle, keyPackage *unsafe.Pointer, length *uintptr)
+	daveSessionGetKeyRatchet             func(session sessionHandle, userID string) keyRatchetHandle
+	daveSessionGetPairwiseFingerprint    func(session sessionHandle, version uint16, userID string, callback uintptr, userData uintptr)
+
+	daveCommitResultDestroy                  func(result commitResultHandle)
+	daveCommitResultIsFailed                 func(result commitResultHandle) bool
+	daveCommitResultIsIgnored                func(result commitResultHandle) bool
+	daveCommitResultGetRosterMemberIds       func(result commitResultHandle, rosterIDs *unsafe.Pointer, length *uintptr)
+	daveCommitResultGetRosterMemberSignature func(result commitResultHandle, rosterID uint64, signature *unsafe.Pointer, length *uintptr)
+
+	daveWelcomeResultDestroy                  func(result welcomeResultHandle)
+	daveWelcomeResultGetRosterMemberIds       func(result welcomeResultHandle, rosterIDs *unsafe.Pointer, length *uintptr)
+	daveWelcomeResultGetRosterMemberSignature func(result welcomeResultHandle, rosterID uint64, signature *unsafe.Pointer, length *uintptr)
+
+	daveKeyRatchetDestroy func(keyRatchet keyRatchetHandle)
+
+	daveEncryptorCreate                            func() encryptionHandle
+	daveEncryptorDestroy                           func(encryptor encryptionHandle)
+	daveEncryptorSetKeyRatchet                     func(encryptor encryptionHandle, keyRatchet keyRatchetHandle)
+	daveEncryptorSetPassthroughMode                func(encryptor encryptionHandle, passthroughMode bool)
+	daveEncryptorAssignSsrcToCodec                 func(encryptor encryptionHandle, ssrc uint32, codec int32)
+	daveEncryptorGetProtocolVersion                func(encryptor encryptionHandle) uint16
+	daveEncryptorGetMaxCiphertextByteSize          func(encryptor encryptionHandle, mediaType int32, frameSize uintptr) uintptr
+	daveEncryptorHasKeyRatchet                     func(encryptor encryptionHandle) bool
+	daveEncryptorIsPassthroughMode                 func(encryptor encryptionHandle) bool
+	daveEncryptorEncrypt                           func(encryptor encryptionHandle, mediaType int32, ssrc uint32, frame *byte, frameLength uintptr, encryptedFrame *byte, encryptedFrameCapacity uintptr, bytesWritten *uintptr) int32
+	daveEncryptorSetProtocolVersionChangedCallback func(encryptor encryptionHandle, callback uintptr, userData uintptr)
+	daveEncryptorGetStats                          func(encryptor encryptionHandle, mediaType int32, stats *EncryptorStats)
+
+	daveDecryptorCreate                      func() decryptorHandle
+	daveDecryptorDestroy                     func(decryptor decryptorHandle)
+	daveDecryptorTransitionToKeyRatchet      func(decryptor decryptorHandle, keyRatchet keyRatchetHandle)
+	daveDecryptorTransitionToPassthroughMode func(decryptor decryptorHandle, passthroughMode bool)
+	daveDecryptorDecrypt                     func(decryptor decryptorHandle, mediaType int32, frame *byte, frameLength uintptr, decryptedFrame *byte, decryptedFrameCapacity uintptr, bytesWritten *uintptr) int32
+	daveDecryptorGetMaxPlaintextByteSize     func(decryptor decryptorHandle, mediaType int32, encryptedFrameSize uintptr) uintptr
+	daveDecryptorGetStats                    func(decryptor decryptorHandle, mediaType int32, stats *DecryptorStats)
+)
+
+// symbols maps the function variables to the libdave symbols they are bound to.
+var symbols = []struct {
+	fn   any
+	name string
+}{
+	// free is resolved through the C runtime libdave is linked against
+	{&free, "free"},
+	{&daveMaxSupportedProtocolVersion, "daveMaxSupportedProtocolVersion"},
+	{&daveSetLogSinkCallback, "daveSetLogSinkCallback"},
+	{&daveSessionCreate, "daveSessionCreate"},
+	{&daveSessionDestroy, "daveSessionDestroy"},
+	{&daveSessionInit, "daveSessionInit"},
+	{&daveSessionReset, "daveSessionReset"},
+	{&daveSessionSetProtocolVersion, "daveSessionSetProtocolVersion"},
+	{&daveSessionGetProtocolVersion, "daveSessionGetProtocolVersion"},
+	{&daveSessionGetLastEpochAuthenticator, "daveSessionGetLastEpochAuthenticator"},
+	{&daveSessionSetExternalSender, "daveSessionSetExternalSender"},
+	{&daveSessionProcessProposals, "daveSessionProcessProposals"},
+	{&daveSessionProcessCommit, "daveSessionProcessCommit"},
+	{&daveSessionProcessWelcome, "daveSessionProcessWelcome"},
+	{&daveSessionGetMarshalledKeyPackage, "daveSessionGetMarshalledKeyPackage"},
+	{&daveSessionGetKeyRatchet, "daveSessionGetKeyRatchet"},
+	{&daveSessionGetPairwiseFingerprint, "daveSessionGetPairwiseFingerprint"},
+	{&daveCommitResultDestroy, "daveCommitResultDestroy"},
+	{&daveCommitResultIsFailed, "daveCommitResultIsFailed"},
+	{&daveCommitResultIsIgnored, "daveCommitResultIsIgnored"},
+	{&daveCommitResultGetRosterMemberIds, "daveCommitResultGetRosterMemberIds"},
+	{&daveCommitResultGetRosterMemberSignature, "daveCommitResultGetRosterMemberSignature"},
+	{&daveWelcomeResultDestroy, "daveWelcomeResultDestroy"},
+	{&daveWelcomeResultGetRosterMemberIds, "daveWelcomeResultGetRosterMemberIds"},
+	{&daveWelcomeResultGetRosterMemberSignature, "daveWelcomeResultGetRosterMemberSignature"},
+	{&daveKeyRatchetDestroy, "daveKeyRatchetDestroy"},
+	{&daveEncryptorCreate, "daveEncryptorCreate"},
+	{&daveEncryptorDestroy, "daveEncryptorDestroy"},
+	{&daveEncryptorSetKeyRatchet, "daveEncryptorSetKeyRatchet"},
+	{&daveEncryptorSetPassthroughMode, "daveEncryptorSetPassthroughMode"},
+	{&daveEncryptorAssignSsrcToCodec, "daveEncryptorAssignSsrcToCodec"},
+	{&daveEncryptorGetProtocolVersion, "daveEncryptorGetProtocolVersion"},
+	{&daveEncryptorGetMaxCiphertextByteSize, "daveEncryptorGetMaxCiphertextByteSize"},
+	{&daveEncryptorHasKeyRatchet, "daveEncryptorHasKeyRatchet"},
+	{&daveEncryptorIsPassthroughMode, "daveEncryptorIsPassthroughMode"},
+	{&daveEncryptorEncrypt, "daveEncryptorEncrypt"},
+	{&daveEncryptorSetProtocolVersionChangedCallback, "daveEncryptorSetProtocolVersionChangedCallback"},
+	{&daveEncryptorGetStats, "daveEncryptorGetStats"},
+	{&daveDecryptorCreate, "daveDecryptorCreate"},
+	{&daveDecryptorDestroy, "daveDecryptorDestroy"},
+	{&daveDecryptorTransitionToKeyRatchet, "daveDecryptorTransitionToKeyRatchet"},
+	{&daveDecryptorTransitionToPassthroughMode, "daveDecryptorTransitionToPassthroughMode"},
+	{&daveDecryptorDecrypt, "daveDecryptorDecrypt"},
+	{&daveDecryptorGetMaxPlaintextByteSize, "daveDecryptorGetMaxPlaintextByteSize"},
+	{&daveDecryptorGetStats, "daveDecryptorGetStats"},
+}
+
+func init() {
+	loadErr = load()
+}
+
+func libraryPath() string {
+	if path := os.Getenv(LibraryPathEnv); path != "" {
+		return path
+	}
+
+	if runtime.GOOS == "darwin" {
+		return "libdave.dylib"
+	}
+	return "libdave.so"
+}
+
+func load() error {
+	path := libraryPath()
+
+	lib, err := purego.Dlopen(path, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+	if err != nil {
+		return fmt.Errorf("%w: failed to load %s, install libdave %s or set %s to its path: %w", ErrUnavailable, path, ExpectedVersion(), LibraryPathEnv, err)
+	}
+
+	for _, symbol := range symbols {
+		addr, err := purego.Dlsym(lib, symbol.name)
+		if err != nil {
+			return fmt.Errorf("%w: %s is missing %s, please reinstall libdave %s: %w", ErrUnavailable, path, symbol.name, ExpectedVersion(), err)
+		}
+		purego.RegisterFunc(symbol.fn, addr)
+	}
+
+	registerCallbacks()
+	daveSetLogSinkCallback(logSinkCallback)
+
+	return nil
+}
+
+// Version returns the libdave release recorded in dave.pc by the libdave install scripts, or an empty string if the
+// loaded libdave was installed without them. dave.pc is looked up next to the library set by LIBDAVE_PATH, in
+// PKG_CONFIG_PATH and in the default install location ~/.local/lib/pkgconfig.
+func Version() string {
+	for _, pcFile := range pkgConfigFiles() {
+		data, err := os.ReadFile(pcFile)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if version, ok := strings.CutPrefix(strings.TrimSpace(line), "Version:"); ok {
+				return strings.TrimSpace(version)
+			}
+		}
+	}
+	return ""
+}
+
+// pkgConfigFiles returns the dave.pc files which may describe the loaded libdave, in order of precedence.
+func pkgConfigFiles() []string {
+	var files []string
+	if path := libraryPath(); filepath.IsAbs(path) {
+		files = append(files, filepath.Join(filepath.Dir(path), "pkgconfig", "dave.pc"))
+	}
+	for _, dir := range filepath.SplitList(os.Getenv("PKG_CONFIG_PATH")) {
+		if dir != "" {
+			files = append(files, filepath.Join(dir, "dave.pc"))
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".local", "lib", "pkgconfig", "dave.pc"))
+	}
+	return files
+}
+
+// Available reports whether libdave was loaded.
+// It returns the error encountered while loading libdave at startup, if any.
+func Available() error {
+	return loadErr
+}
+
+// mustLoad panics with the error encountered loading libdave, if any.
+func mustLoad() {
+	if loadErr != nil {
+		panic(loadErr)
+	}
+}
+
+// MaxSupportedProtocolVersion returns the maximum supported libdave protocol version.
+func MaxSupportedProtocolVersion() uint16 {
+	mustLoad()
+	return daveMaxSupportedProtocolVersion()
+}
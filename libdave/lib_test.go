@@ -1,13 +1,55 @@
 package libdave
 
 import (
+	"os"
+	"strings"
 	"testing"
 )
 
+// skipUnavailable skips tb when libdave is not available, such as when built without cgo or the libdave_purego tag.
+func skipUnavailable(tb testing.TB) {
+	tb.Helper()
+	if err := Available(); err != nil {
+		tb.Skip(err)
+	}
+}
+
 func TestMaxSupportedProtocolVersion(t *testing.T) {
+	skipUnavailable(t)
 	maxSupportedProtocolVersion := MaxSupportedProtocolVersion()
 
-	if maxSupportedProtocolVersion != 1 {
-		t.Errorf("expected 1, got %d", maxSupportedProtocolVersion)
+	if maxSupportedProtocolVersion != ExpectedProtocolVersion {
+		t.Errorf("expected %d, got %d", ExpectedProtocolVersion, maxSupportedProtocolVersion)
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	skipUnavailable(t)
+	if err := CheckVersion(); err != nil {
+		t.Errorf("expected compatible libdave, got %v", err)
+	}
+	if Version() != ExpectedVersion() {
+		t.Errorf("expected libdave %s, got %s", ExpectedVersion(), Version())
+	}
+
+	data, err := os.ReadFile("release.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.TrimSpace(string(data)); ExpectedVersion() != want {
+		t.Errorf("expected %s, got %s", want, ExpectedVersion())
+	}
+}
+
+func TestVersionError(t *testing.T) {
+	err := &VersionError{
+		ExpectedVersion:         "v1.1.0",
+		ExpectedProtocolVersion: 1,
+		ProtocolVersion:         1,
+	}
+	for _, want := range []string{"unknown release", "libdave_install.sh v1.1.0", "libdave_install.ps1 v1.1.0", "cmd/libdave-install -version v1.1.0"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q to contain %q", err.Error(), want)
+		}
 	}
 }
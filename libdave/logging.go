@@ -1,13 +1,9 @@
 package libdave
 
-// #include "dave.h"
-// extern void godaveGlobalLogCallback(DAVELoggingSeverity severity, char* file, int line, char* message);
-import "C"
 import (
 	"context"
 	"log/slog"
 	"sync/atomic"
-	"unsafe"
 )
 
 var (
@@ -20,27 +16,36 @@ func init() {
 	SetDefaultLogger(slog.New(newLogWrapper(slog.Default().Handler())).
 		With(slog.String("name", "libdave")),
 	)
-
-	C.daveSetLogSinkCallback(C.DAVELogSinkCallback(unsafe.Pointer(C.godaveGlobalLogCallback)))
 }
 
-//export godaveGlobalLogCallback
-func godaveGlobalLogCallback(severity C.DAVELoggingSeverity, file *C.char, line C.int, message *C.char) {
+// logSeverity mirrors DAVELoggingSeverity.
+type logSeverity int
+
+const (
+	logSeverityVerbose logSeverity = iota
+	logSeverityInfo
+	logSeverityWarning
+	logSeverityError
+	logSeverityNone
+)
+
+// logMessage logs a message received from the libdave log sink.
+func logMessage(severity logSeverity, file string, line int, message string) {
 	var slogSeverity slog.Level
 	switch severity {
-	case C.DAVE_LOGGING_SEVERITY_VERBOSE:
+	case logSeverityVerbose:
 		slogSeverity = slog.LevelDebug
-	case C.DAVE_LOGGING_SEVERITY_INFO:
+	case logSeverityInfo:
 		slogSeverity = slog.LevelInfo
-	case C.DAVE_LOGGING_SEVERITY_WARNING:
+	case logSeverityWarning:
 		slogSeverity = slog.LevelWarn
-	case C.DAVE_LOGGING_SEVERITY_ERROR:
+	case logSeverityError:
 		slogSeverity = slog.LevelError
-	case C.DAVE_LOGGING_SEVERITY_NONE:
+	case logSeverityNone:
 		return
 	}
 
-	defaultLogger.Load().Log(context.Background(), slogSeverity, C.GoString(message), slog.String("file", C.GoString(file)), slog.Int("line", int(line)))
+	defaultLogger.Load().Log(context.Background(), slogSeverity, message, slog.String("file", file), slog.Int("line", line))
 }
 
 // SetDefaultLogger sets the default logger used by libdave.
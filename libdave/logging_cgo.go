@@ -0,0 +1,19 @@
+//go:build !libdave_purego
+
+package libdave
+
+// #include "dave.h"
+// extern void godaveGlobalLogCallback(DAVELoggingSeverity severity, char* file, int line, char* message);
+import "C"
+import (
+	"unsafe"
+)
+
+func init() {
+	C.daveSetLogSinkCallback(C.DAVELogSinkCallback(unsafe.Pointer(C.godaveGlobalLogCallback)))
+}
+
+//export godaveGlobalLogCallback
+func godaveGlobalLogCallback(severity C.DAVELoggingSeverity, file *C.char, line C.int, message *C.char) {
+	logMessage(logSeverity(severity), C.GoString(file), int(line), C.GoString(message))
+}
@@ -0,0 +1,51 @@
+package libdave
+
+type encryptorResultCode int
+
+const (
+	encryptorResultCodeSuccess encryptorResultCode = iota
+	encryptorResultCodeEncryptionFailure
+	encryptorResultCodeMissingKeyRatchet
+	encryptionResultCodeMissingCryptor
+	encryptionResultCodeTooManyAttempts
+)
+
+func (r encryptorResultCode) ToError() error {
+	switch r {
+	case encryptorResultCodeSuccess:
+		return nil
+	case encryptorResultCodeMissingKeyRatchet:
+		return ErrMissingKeyRatchet
+	case encryptionResultCodeMissingCryptor:
+		return ErrMissingCryptor
+	case encryptionResultCodeTooManyAttempts:
+		return ErrTooManyAttempts
+	default:
+		return ErrGenericEncryptionFailure
+	}
+}
+
+type decryptorResultCode int
+
+const (
+	decryptorResultCodeSuccess decryptorResultCode = iota
+	decryptorResultCodeDecryptionFailure
+	decryptorResultCodeMissingKeyRatchet
+	decryptorResultCodeInvalidNonce
+	decryptorResultCodeMissingCryptor
+)
+
+func (r decryptorResultCode) ToError() error {
+	switch r {
+	case decryptorResultCodeSuccess:
+		return nil
+	case decryptorResultCodeMissingKeyRatchet:
+		return ErrMissingKeyRatchet
+	case decryptorResultCodeInvalidNonce:
+		return ErrInvalidNonce
+	case decryptorResultCodeMissingCryptor:
+		return ErrMissingCryptor
+	default:
+		return ErrGenericDecryptionFailure
+	}
+}
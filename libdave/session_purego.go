@@ -0,0 +1,136 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+type sessionHandle = uintptr
+
+type Session struct {
+	handle sessionHandle
+}
+
+func NewSession(context string, authSessionID string) *Session {
+	mustLoad()
+
+	cContext := append([]byte(context), 0)
+	authSessionIDHandler := newHandle(authSessionID)
+
+	session := &Session{
+		handle: daveSessionCreate(
+			unsafe.Pointer(&cContext[0]),
+			authSessionID,
+			failureCallback,
+			uintptr(authSessionIDHandler),
+		),
+	}
+	runtime.KeepAlive(cContext)
+
+	runtime.SetFinalizer(session, func(s *Session) {
+		daveSessionDestroy(s.handle)
+		authSessionIDHandler.delete()
+	})
+
+	return session
+}
+
+func (s *Session) Init(version uint16, channelID uint64, selfUserID string) {
+	daveSessionInit(s.handle, version, channelID, selfUserID)
+}
+
+func (s *Session) Reset() {
+	daveSessionReset(s.handle)
+}
+
+func (s *Session) SetProtocolVersion(version uint16) {
+	daveSessionSetProtocolVersion(s.handle, version)
+}
+
+func (s *Session) GetProtocolVersion() uint16 {
+	return daveSessionGetProtocolVersion(s.handle)
+}
+
+func (s *Session) GetLastEpochAuthenticator() []byte {
+	var (
+		authenticator    unsafe.Pointer
+		authenticatorLen uintptr
+	)
+	daveSessionGetLastEpochAuthenticator(s.handle, &authenticator, &authenticatorLen)
+
+	return newByteSlice(authenticator, authenticatorLen)
+}
+
+func (s *Session) SetExternalSender(externalSender []byte) {
+	daveSessionSetExternalSender(s.handle, &externalSender[0], uintptr(len(externalSender)))
+}
+
+func (s *Session) ProcessProposals(proposals []byte, recognizedUserIDs []string) []byte {
+	cRecognizedUserIDs, unpin := stringSliceToC(recognizedUserIDs)
+	defer unpin()
+
+	var (
+		welcomeBytes    unsafe.Pointer
+		welcomeBytesLen uintptr
+	)
+	daveSessionProcessProposals(
+		s.handle,
+		&proposals[0],
+		uintptr(len(proposals)),
+		cRecognizedUserIDs,
+		uintptr(len(recognizedUserIDs)),
+		&welcomeBytes,
+		&welcomeBytesLen,
+	)
+
+	return newByteSlice(welcomeBytes, welcomeBytesLen)
+}
+
+func (s *Session) ProcessCommit(commit []byte) *CommitResult {
+	return newCommitResult(daveSessionProcessCommit(s.handle, &commit[0], uintptr(len(commit))))
+}
+
+func (s *Session) ProcessWelcome(welcome []byte, recognizedUserIDs []string) *WelcomeResult {
+	cRecognizedUserIDs, unpin := stringSliceToC(recognizedUserIDs)
+	defer unpin()
+
+	return newWelcomeResult(daveSessionProcessWelcome(
+		s.handle,
+		&welcome[0],
+		uintptr(len(welcome)),
+		cRecognizedUserIDs,
+		uintptr(len(recognizedUserIDs)),
+	))
+}
+
+func (s *Session) GetMarshalledKeyPackage() []byte {
+	var (
+		keyPackage    unsafe.Pointer
+		keyPackageLen uintptr
+	)
+	daveSessionGetMarshalledKeyPackage(s.handle, &keyPackage, &keyPackageLen)
+
+	return newByteSlice(keyPackage, keyPackageLen)
+}
+
+func (s *Session) GetKeyRatchet(userID string) *KeyRatchet {
+	return newKeyRatchet(daveSessionGetKeyRatchet(s.handle, userID))
+}
+
+func (s *Session) GetPairwiseFingerprint(version uint16, userID string) []byte {
+	ch := make(chan []byte)
+	handler := newHandle(ch)
+	defer handler.delete()
+
+	daveSessionGetPairwiseFingerprint(
+		s.handle,
+		version,
+		userID,
+		pairwiseFingerprintCallback,
+		uintptr(handler),
+	)
+
+	return <-ch
+}
@@ -0,0 +1,21 @@
+package libdave
+
+type EncryptorStats struct {
+	PassthroughCount       uint64
+	EncryptSuccessCount    uint64
+	EncryptFailureCount    uint64
+	EncryptDuration        uint64
+	EncryptAttempts        uint64
+	EncryptMaxAttempts     uint64
+	EncryptMissingKeyCount uint64
+}
+
+type DecryptorStats struct {
+	PassthroughCount         uint64
+	DecryptSuccessCount      uint64
+	DecryptFailureCount      uint64
+	DecryptDuration          uint64
+	DecryptAttempts          uint64
+	DecryptMissingKeyCount   uint64
+	DecryptInvalidNonceCount uint64
+}
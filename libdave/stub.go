@@ -0,0 +1,96 @@
+//go:build !(cgo && !libdave_purego) && !(libdave_purego && (darwin || freebsd || linux || netbsd))
+
+package libdave
+
+import "fmt"
+
+// This file allows the package to compile without cgo. Every constructor panics with ErrUnavailable,
+// check Available before using the package.
+
+func Available() error {
+	return fmt.Errorf("%w: build with cgo enabled or with the libdave_purego tag", ErrUnavailable)
+}
+
+func MaxSupportedProtocolVersion() uint16 {
+	return 0
+}
+
+func Version() string {
+	return ""
+}
+
+type Session struct{}
+
+func NewSession(_ string, _ string) *Session {
+	panic(ErrUnavailable)
+}
+
+func (s *Session) Init(_ uint16, _ uint64, _ string)                  {}
+func (s *Session) Reset()                                             {}
+func (s *Session) SetProtocolVersion(_ uint16)                        {}
+func (s *Session) GetProtocolVersion() uint16                         { return 0 }
+func (s *Session) GetLastEpochAuthenticator() []byte                  { return nil }
+func (s *Session) SetExternalSender(_ []byte)                         {}
+func (s *Session) ProcessProposals(_ []byte, _ []string) []byte       { return nil }
+func (s *Session) ProcessCommit(_ []byte) *CommitResult               { return &CommitResult{} }
+func (s *Session) ProcessWelcome(_ []byte, _ []string) *WelcomeResult { return nil }
+func (s *Session) GetMarshalledKeyPackage() []byte                    { return nil }
+func (s *Session) GetKeyRatchet(_ string) *KeyRatchet                 { return &KeyRatchet{} }
+func (s *Session) GetPairwiseFingerprint(_ uint16, _ string) []byte   { return nil }
+
+type CommitResult struct{}
+
+func (r *CommitResult) IsFailed() bool                           { return true }
+func (r *CommitResult) IsIgnored() bool                          { return false }
+func (r *CommitResult) GetRosterMemberIDs() []uint64             { return nil }
+func (r *CommitResult) GetRosterMemberSignature(_ uint64) []byte { return nil }
+
+type WelcomeResult struct{}
+
+func (w *WelcomeResult) GetRosterMemberIDs() []uint64             { return nil }
+func (w *WelcomeResult) GetRosterMemberSignature(_ uint64) []byte { return nil }
+
+type KeyRatchet struct{}
+
+type Encryptor struct{}
+
+func NewEncryptor() *Encryptor {
+	panic(ErrUnavailable)
+}
+
+func (e *Encryptor) HasKeyRatchet() bool                                     { return false }
+func (e *Encryptor) IsPassthroughMode() bool                                 { return true }
+func (e *Encryptor) SetKeyRatchet(_ *KeyRatchet)                             {}
+func (e *Encryptor) SetPassthroughMode(_ bool)                               {}
+func (e *Encryptor) AssignSsrcToCodec(_ uint32, _ Codec)                     {}
+func (e *Encryptor) GetProtocolVersion() uint16                              { return 0 }
+func (e *Encryptor) GetMaxCiphertextByteSize(_ MediaType, frameSize int) int { return frameSize }
+func (e *Encryptor) Encrypt(_ MediaType, _ uint32, _ []byte, _ []byte) (int, error) {
+	return 0, ErrUnavailable
+}
+func (e *Encryptor) EncryptBatch(frames []EncryptFrame) {
+	for i := range frames {
+		frames[i].Err = ErrUnavailable
+	}
+}
+func (e *Encryptor) GetStats(_ MediaType) *EncryptorStats { return &EncryptorStats{} }
+
+type Decryptor struct{}
+
+func NewDecryptor() *Decryptor {
+	panic(ErrUnavailable)
+}
+
+func (d *Decryptor) TransitionToKeyRatchet(_ *KeyRatchet)                   {}
+func (d *Decryptor) TransitionToPassthroughMode(_ bool)                     {}
+func (d *Decryptor) GetMaxPlaintextByteSize(_ MediaType, frameSize int) int { return frameSize }
+func (d *Decryptor) Decrypt(_ MediaType, _ []byte, _ []byte) (int, error) {
+	return 0, ErrUnavailable
+}
+func (d *Decryptor) GetStats(_ MediaType) *DecryptorStats { return &DecryptorStats{} }
+
+func DecryptBatch(frames []DecryptFrame) {
+	for i := range frames {
+		frames[i].Err = ErrUnavailable
+	}
+}
@@ -1,12 +1,23 @@
+//go:build !libdave_purego
+
 package libdave
 
 // #include <stdlib.h>
 // #include <stdint.h>
 import "C"
 import (
+	"sync"
 	"unsafe"
 )
 
+// bytesWrittenPool pools the bytesWritten out parameter of encrypt and decrypt calls,
+// which would otherwise be allocated on the heap for every frame because it is passed to C.
+var bytesWrittenPool = sync.Pool{
+	New: func() any {
+		return new(C.size_t)
+	},
+}
+
 func stringSliceToC(strings []string) (**C.char, func()) {
 	cArray := make([]*C.char, len(strings))
 	for i, s := range strings {
@@ -0,0 +1,72 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// bytesWrittenPool pools the bytesWritten out parameter of encrypt and decrypt calls,
+// which would otherwise be allocated on the heap for every frame because it is passed to libdave.
+var bytesWrittenPool = sync.Pool{
+	New: func() any {
+		return new(uintptr)
+	},
+}
+
+// stringSliceToC returns a C array of C strings. The array and the strings are Go memory pinned with a
+// runtime.Pinner, since libdave reads the Go pointers stored in the array. The returned function unpins them
+// and must be called after the C call using them.
+func stringSliceToC(strings []string) (**byte, func()) {
+	var pinner runtime.Pinner
+	cArray := make([]*byte, len(strings))
+	for i, s := range strings {
+		cString := append([]byte(s), 0)
+		pinner.Pin(&cString[0])
+		cArray[i] = &cString[0]
+	}
+	pinner.Pin(&cArray[0])
+
+	return &cArray[0], pinner.Unpin
+}
+
+// goString copies a null-terminated C string into a Go string.
+func goString(cString *byte) string {
+	if cString == nil {
+		return ""
+	}
+
+	var length int
+	for *(*byte)(unsafe.Add(unsafe.Pointer(cString), length)) != 0 {
+		length++
+	}
+	return string(unsafe.Slice(cString, length))
+}
+
+// IMPORTANT: This function will free the underlying C memory, so cArray becomes unsafe to use
+// after this function call
+func newByteSlice(cArray unsafe.Pointer, length uintptr) []byte {
+	view := unsafe.Slice((*byte)(cArray), length)
+
+	slice := make([]byte, length)
+	copy(slice, view)
+
+	free(cArray)
+
+	return slice
+}
+
+// IMPORTANT: This function will free the underlying C memory, so cArray becomes unsafe to use
+// after this function call
+func newUint64Slice(cArray unsafe.Pointer, length uintptr) []uint64 {
+	view := unsafe.Slice((*uint64)(cArray), length)
+
+	slice := make([]uint64, length)
+	copy(slice, view)
+
+	free(cArray)
+
+	return slice
+}
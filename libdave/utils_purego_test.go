@@ -0,0 +1,20 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestStringSliceToC(t *testing.T) {
+	strings := []string{"1234", "", "5678"}
+	cArray, unpin := stringSliceToC(strings)
+	defer unpin()
+
+	for i, cString := range unsafe.Slice(cArray, len(strings)) {
+		if s := goString(cString); s != strings[i] {
+			t.Errorf("expected %q, got %q", strings[i], s)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package libdave
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed release.txt
+var release string
+
+// ExpectedProtocolVersion is the maximum protocol version supported by the libdave release in release.txt.
+const ExpectedProtocolVersion = 1
+
+// ExpectedVersion returns the libdave release these bindings are built against, as recorded in release.txt.
+func ExpectedVersion() string {
+	return strings.TrimSpace(release)
+}
+
+// VersionError is returned by CheckVersion if the loaded libdave does not match ExpectedVersion.
+type VersionError struct {
+	// ExpectedVersion is the libdave release these bindings are built against.
+	ExpectedVersion string
+	// Version is the release of the loaded libdave, or empty if it was installed without the libdave install scripts.
+	Version string
+	// ExpectedProtocolVersion is the maximum protocol version supported by ExpectedVersion.
+	ExpectedProtocolVersion uint16
+	// ProtocolVersion is the maximum protocol version supported by the loaded libdave.
+	ProtocolVersion uint16
+}
+
+func (e *VersionError) Error() string {
+	version := e.Version
+	if version == "" {
+		version = "of unknown release"
+	}
+	return fmt.Sprintf("libdave: loaded libdave %s supports protocol version %d but libdave %s (protocol version %d) is required, "+
+		"please reinstall libdave with scripts/libdave_install.sh %[3]s, scripts/libdave_install.ps1 %[3]s "+
+		"or go run github.com/disgoorg/godave/cmd/libdave-install -version %[3]s",
+		version, e.ProtocolVersion, e.ExpectedVersion, e.ExpectedProtocolVersion,
+	)
+}
+
+// CheckVersion checks that the loaded libdave is the release in release.txt, as recorded by the libdave install
+// scripts, and supports ExpectedProtocolVersion. It returns a *VersionError describing how to fix the installation if not.
+func CheckVersion() error {
+	version := Version()
+	protocolVersion := MaxSupportedProtocolVersion()
+	if version != ExpectedVersion() || protocolVersion != ExpectedProtocolVersion {
+		return &VersionError{
+			ExpectedVersion:         ExpectedVersion(),
+			Version:                 version,
+			ExpectedProtocolVersion: ExpectedProtocolVersion,
+			ProtocolVersion:         protocolVersion,
+		}
+	}
+	return nil
+}
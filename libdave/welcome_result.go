@@ -1,3 +1,5 @@
+//go:build !libdave_purego
+
 package libdave
 
 // #include "dave.h"
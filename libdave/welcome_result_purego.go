@@ -0,0 +1,50 @@
+//go:build libdave_purego && (darwin || freebsd || linux || netbsd)
+
+package libdave
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+type welcomeResultHandle = uintptr
+
+type WelcomeResult struct {
+	handle welcomeResultHandle
+}
+
+func newWelcomeResult(handle welcomeResultHandle) *WelcomeResult {
+	if handle == 0 {
+		return nil
+	}
+
+	welcomeResult := &WelcomeResult{
+		handle: handle,
+	}
+
+	runtime.SetFinalizer(welcomeResult, func(s *WelcomeResult) {
+		daveWelcomeResultDestroy(s.handle)
+	})
+
+	return welcomeResult
+}
+
+func (w *WelcomeResult) GetRosterMemberIDs() []uint64 {
+	var (
+		rosterIDs       unsafe.Pointer
+		rosterIDsLength uintptr
+	)
+	daveWelcomeResultGetRosterMemberIds(w.handle, &rosterIDs, &rosterIDsLength)
+
+	return newUint64Slice(rosterIDs, rosterIDsLength)
+}
+
+func (w *WelcomeResult) GetRosterMemberSignature(rosterID uint64) []byte {
+	var (
+		rosterMemberSignature       unsafe.Pointer
+		rosterMemberSignatureLength uintptr
+	)
+	daveWelcomeResultGetRosterMemberSignature(w.handle, rosterID, &rosterMemberSignature, &rosterMemberSignatureLength)
+
+	return newByteSlice(rosterMemberSignature, rosterMemberSignatureLength)
+}
@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"log/slog"
+)
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		Logger: slog.Default(),
+	}
+}
+
+// Config is the configuration used by managers created with New.
+type Config struct {
+	// Logger logs sessions which failed to close.
+	Logger *slog.Logger
+	// MaxSessions is the maximum number of open sessions, 0 for no limit.
+	MaxSessions int
+}
+
+// ConfigOpt is a type alias for a function that takes a Config and is used to configure your manager.
+type ConfigOpt func(config *Config)
+
+// Apply applies the given ConfigOpt(s) to the Config.
+func (c *Config) Apply(opts []ConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithLogger sets the logger used to log sessions which failed to close.
+func WithLogger(logger *slog.Logger) ConfigOpt {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithMaxSessions sets the maximum number of open sessions, 0 for no limit.
+func WithMaxSessions(maxSessions int) ConfigOpt {
+	return func(config *Config) {
+		config.MaxSessions = maxSessions
+	}
+}
@@ -0,0 +1,283 @@
+// Package manager keeps track of the sessions of many voice connections, for example those of a sharded bot.
+//
+// A Manager creates sessions with any godave.SessionCreateFunc and indexes them by guild and channel. A bot has
+// at most one voice connection per guild, so creating a session for a guild closes its previous one. Moving to
+// another channel starts a new DAVE session on the voice gateway, Move closes the session and creates a new one.
+// Sessions are closed exactly once, whether by Session.Close, Disconnect, Move or Manager.Close.
+package manager
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/godave"
+)
+
+var (
+	ErrMaxSessions    = errors.New("manager: maximum number of sessions reached")
+	ErrClosed         = errors.New("manager: closed")
+	ErrUnknownSession = errors.New("manager: no session for guild")
+)
+
+// GuildID is the ID of the guild of a voice connection.
+type GuildID uint64
+
+// New returns a Manager creating sessions with create.
+func New(create godave.SessionCreateFunc, opts ...ConfigOpt) *Manager {
+	config := DefaultConfig()
+	config.Apply(opts)
+
+	return &Manager{
+		create:   create,
+		config:   config,
+		guilds:   make(map[GuildID]*Session),
+		channels: make(map[godave.ChannelID]*Session),
+	}
+}
+
+// Manager creates and indexes the sessions of voice connections. It is safe for concurrent use.
+type Manager struct {
+	create godave.SessionCreateFunc
+	config *Config
+
+	mu       sync.Mutex
+	guilds   map[GuildID]*Session
+	channels map[godave.ChannelID]*Session
+	// pending is the number of sessions being created, they count towards MaxSessions.
+	pending int
+	closed  bool
+
+	created     uint64
+	closedCount uint64
+	rejected    uint64
+	closeErrors uint64
+}
+
+// Stats are the aggregated statistics of the sessions of a Manager.
+type Stats struct {
+	// Sessions is the number of open sessions.
+	Sessions int
+	// Ready is the number of open sessions whose Ready returns true.
+	Ready int
+	// Created and Closed are the number of sessions created and closed since the Manager was created.
+	Created uint64
+	Closed  uint64
+	// Rejected is the number of sessions which were not created as MaxSessions was reached.
+	Rejected uint64
+	// CloseErrors is the number of sessions which returned an error from Close.
+	CloseErrors uint64
+}
+
+// Create creates the session of the voice connection to the channel of the guild. The previous session of the
+// guild is closed. It returns ErrMaxSessions if MaxSessions sessions are open and ErrClosed after Close.
+func (m *Manager) Create(guildID GuildID, channelID godave.ChannelID, userID godave.UserID, callbacks godave.Callbacks) (*Session, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrClosed
+	}
+	previous := m.guilds[guildID]
+	open := len(m.guilds) + m.pending
+	if previous != nil {
+		open--
+	}
+	if m.config.MaxSessions > 0 && open >= m.config.MaxSessions {
+		m.rejected++
+		m.mu.Unlock()
+		return nil, ErrMaxSessions
+	}
+	if previous != nil {
+		m.removeLocked(previous)
+	}
+	m.pending++
+	m.mu.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+
+	s := &Session{
+		manager:   m,
+		guildID:   guildID,
+		channelID: channelID,
+		userID:    userID,
+		callbacks: callbacks,
+		createdAt: time.Now(),
+	}
+	s.Session = m.create(m.config.Logger.With(slog.Uint64("guild_id", uint64(guildID)), slog.Uint64("channel_id", uint64(channelID))), userID, callbacks)
+	if channelID != 0 {
+		s.Session.SetChannelID(channelID)
+	}
+
+	m.mu.Lock()
+	m.pending--
+	m.created++
+	if m.closed {
+		m.mu.Unlock()
+		s.close()
+		return nil, ErrClosed
+	}
+	// a concurrent Create for the same guild may have finished first
+	replaced := m.guilds[guildID]
+	if replaced != nil {
+		m.removeLocked(replaced)
+	}
+	m.guilds[guildID] = s
+	if channelID != 0 {
+		m.channels[channelID] = s
+	}
+	m.mu.Unlock()
+
+	if replaced != nil {
+		replaced.close()
+	}
+	return s, nil
+}
+
+// Move closes the session of the guild and creates a new one for the channel, as the voice gateway starts a new
+// DAVE session when moving to another channel. It returns ErrUnknownSession if the guild has no session.
+func (m *Manager) Move(guildID GuildID, channelID godave.ChannelID) (*Session, error) {
+	s := m.Guild(guildID)
+	if s == nil {
+		return nil, ErrUnknownSession
+	}
+	return m.Create(guildID, channelID, s.userID, s.callbacks)
+}
+
+// Disconnect closes the session of the guild. It does nothing if the guild has no session.
+func (m *Manager) Disconnect(guildID GuildID) error {
+	if s := m.Guild(guildID); s != nil {
+		return s.Close()
+	}
+	return nil
+}
+
+// Guild returns the session of the guild or nil.
+func (m *Manager) Guild(guildID GuildID) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.guilds[guildID]
+}
+
+// Channel returns the session of the channel or nil.
+func (m *Manager) Channel(channelID godave.ChannelID) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.channels[channelID]
+}
+
+// Sessions returns the open sessions ordered by guild.
+func (m *Manager) Sessions() []*Session {
+	m.mu.Lock()
+	sessions := make([]*Session, 0, len(m.guilds))
+	for _, s := range m.guilds {
+		sessions = append(sessions, s)
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(sessions, func(a, b *Session) int {
+		switch {
+		case a.guildID < b.guildID:
+			return -1
+		case a.guildID > b.guildID:
+			return 1
+		}
+		return 0
+	})
+	return sessions
+}
+
+// Len returns the number of open sessions.
+func (m *Manager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.guilds)
+}
+
+// Ready reports whether all open sessions are ready.
+func (m *Manager) Ready() bool {
+	for _, s := range m.Sessions() {
+		if !s.Ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// NotReady returns the open sessions which are not ready, ordered by guild.
+func (m *Manager) NotReady() []*Session {
+	var notReady []*Session
+	for _, s := range m.Sessions() {
+		if !s.Ready() {
+			notReady = append(notReady, s)
+		}
+	}
+	return notReady
+}
+
+// Stats returns the aggregated statistics of the sessions.
+func (m *Manager) Stats() Stats {
+	sessions := m.Sessions()
+
+	m.mu.Lock()
+	stats := Stats{
+		Created:     m.created,
+		Closed:      m.closedCount,
+		Rejected:    m.rejected,
+		CloseErrors: m.closeErrors,
+	}
+	m.mu.Unlock()
+
+	stats.Sessions = len(sessions)
+	for _, s := range sessions {
+		if s.Ready() {
+			stats.Ready++
+		}
+	}
+	return stats
+}
+
+// Close closes all sessions. Create returns ErrClosed afterward.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	sessions := make([]*Session, 0, len(m.guilds))
+	for _, s := range m.guilds {
+		sessions = append(sessions, s)
+		m.removeLocked(s)
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, s := range sessions {
+		errs = append(errs, s.close())
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) removeLocked(s *Session) {
+	if m.guilds[s.guildID] == s {
+		delete(m.guilds, s.guildID)
+	}
+	if m.channels[s.channelID] == s {
+		delete(m.channels, s.channelID)
+	}
+}
+
+func (m *Manager) remove(s *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeLocked(s)
+}
+
+func (m *Manager) sessionClosed(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closedCount++
+	if err != nil {
+		m.closeErrors++
+	}
+}
@@ -0,0 +1,135 @@
+package manager
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/disgoorg/godave"
+)
+
+type fakeSession struct {
+	godave.Session
+	ready  atomic.Bool
+	closes atomic.Int32
+}
+
+func (s *fakeSession) Ready() bool {
+	return s.ready.Load()
+}
+
+func (s *fakeSession) SetChannelID(godave.ChannelID) {}
+
+func (s *fakeSession) Close() error {
+	s.closes.Add(1)
+	return nil
+}
+
+type fakeSessions struct {
+	mu       sync.Mutex
+	sessions []*fakeSession
+}
+
+func (f *fakeSessions) create(logger *slog.Logger, userID godave.UserID, callbacks godave.Callbacks) godave.Session {
+	s := &fakeSession{Session: godave.NewNoopSession(logger, userID, callbacks)}
+	f.mu.Lock()
+	f.sessions = append(f.sessions, s)
+	f.mu.Unlock()
+	return s
+}
+
+func (f *fakeSessions) closed(t *testing.T) int {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, s := range f.sessions {
+		switch closes := s.closes.Load(); closes {
+		case 0:
+		case 1:
+			n++
+		default:
+			t.Errorf("expected session to be closed once, got %d closes", closes)
+		}
+	}
+	return n
+}
+
+func TestManager(t *testing.T) {
+	fake := &fakeSessions{}
+	m := New(fake.create, WithLogger(slog.New(slog.DiscardHandler)), WithMaxSessions(2))
+
+	a, err := m.Create(1, 10, "bot", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Create(2, 20, "bot", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Create(3, 30, "bot", nil); !errors.Is(err, ErrMaxSessions) {
+		t.Fatalf("expected ErrMaxSessions, got %v", err)
+	}
+	if m.Channel(10) != a || m.Guild(1) != a {
+		t.Error("expected session to be indexed by guild and channel")
+	}
+
+	// replacing the session of a guild does not count towards the limit
+	moved, err := m.Move(1, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.closed(t) != 1 || m.Channel(10) != nil || m.Channel(11) != moved || moved.GuildID() != 1 {
+		t.Error("expected move to close the previous session and index the new one")
+	}
+	_ = a.Close()
+
+	fake.sessions[1].ready.Store(true)
+	if m.Ready() || len(m.NotReady()) != 1 || m.NotReady()[0] != moved {
+		t.Error("expected moved session not to be ready")
+	}
+	want := Stats{Sessions: 2, Ready: 1, Created: 3, Closed: 1, Rejected: 1}
+	if stats := m.Stats(); stats != want {
+		t.Errorf("expected stats %+v, got %+v", want, stats)
+	}
+
+	if err = m.Disconnect(2); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if fake.closed(t) != 3 || m.Len() != 0 {
+		t.Errorf("expected all sessions to be closed, got %d open", m.Len())
+	}
+	if _, err = m.Create(1, 10, "bot", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestManagerConcurrent(t *testing.T) {
+	fake := &fakeSessions{}
+	m := New(fake.create, WithLogger(slog.New(slog.DiscardHandler)), WithMaxSessions(4))
+
+	var wg sync.WaitGroup
+	for i := range 64 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			guildID := GuildID(i % 8)
+			if s, err := m.Create(guildID, godave.ChannelID(i), "bot", nil); err == nil && i%3 == 0 {
+				_ = s.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := m.Len(); n > 4 {
+		t.Errorf("expected at most 4 sessions, got %d", n)
+	}
+	_ = m.Close()
+	if closed := fake.closed(t); closed != len(fake.sessions) {
+		t.Errorf("expected all %d sessions to be closed, got %d", len(fake.sessions), closed)
+	}
+}
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/godave"
+)
+
+var _ godave.Session = (*Session)(nil)
+
+// Session is a godave.Session created by a Manager. Closing it removes it from the Manager.
+type Session struct {
+	godave.Session
+
+	manager   *Manager
+	guildID   GuildID
+	userID    godave.UserID
+	callbacks godave.Callbacks
+	createdAt time.Time
+	// channelID is guarded by the mutex of the manager.
+	channelID godave.ChannelID
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// GuildID returns the guild of the voice connection.
+func (s *Session) GuildID() GuildID {
+	return s.guildID
+}
+
+// ChannelID returns the channel of the voice connection.
+func (s *Session) ChannelID() godave.ChannelID {
+	s.manager.mu.Lock()
+	defer s.manager.mu.Unlock()
+	return s.channelID
+}
+
+// CreatedAt returns when the session was created.
+func (s *Session) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+// SetChannelID sets the channel of the session and indexes it by the channel. Use Manager.Move instead when moving
+// to another channel, the voice gateway starts a new DAVE session in that case.
+func (s *Session) SetChannelID(channelID godave.ChannelID) {
+	m := s.manager
+	m.mu.Lock()
+	if m.channels[s.channelID] == s {
+		delete(m.channels, s.channelID)
+	}
+	s.channelID = channelID
+	// closed sessions are not indexed again
+	if m.guilds[s.guildID] == s && channelID != 0 {
+		m.channels[channelID] = s
+	}
+	m.mu.Unlock()
+
+	s.Session.SetChannelID(channelID)
+}
+
+// Close removes the session from the Manager and closes it. Only the first call closes the session,
+// later calls return the error of the first one.
+func (s *Session) Close() error {
+	s.manager.remove(s)
+	return s.close()
+}
+
+func (s *Session) close() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.Session.Close()
+		if s.closeErr != nil {
+			s.manager.config.Logger.Error("failed to close session", slog.Uint64("guild_id", uint64(s.guildID)), slog.Any("err", s.closeErr))
+		}
+		s.manager.sessionClosed(s.closeErr)
+	})
+	return s.closeErr
+}
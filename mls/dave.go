@@ -0,0 +1,132 @@
+package mls
+
+// The payloads of the DAVE voice gateway opcodes, see https://daveprotocol.com.
+
+// ProposalsOperation is the operation of a dave_mls_proposals payload.
+type ProposalsOperation uint8
+
+const (
+	ProposalsOperationAppend ProposalsOperation = iota
+	ProposalsOperationRevoke
+)
+
+func (o ProposalsOperation) String() string {
+	return enumName(map[ProposalsOperation]string{
+		ProposalsOperationAppend: "append",
+		ProposalsOperationRevoke: "revoke",
+	}, o)
+}
+
+func (o ProposalsOperation) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// Proposals is the payload of the dave_mls_proposals opcode passed to godave.Session.OnDaveMLSProposals.
+type Proposals struct {
+	Operation ProposalsOperation `json:"operation"`
+	// Messages are the proposals to append, set for ProposalsOperationAppend.
+	Messages []*MLSMessage `json:"messages,omitempty"`
+	// Refs are the references of the proposals to revoke, set for ProposalsOperationRevoke.
+	Refs []Bytes `json:"refs,omitempty"`
+}
+
+// CommitWelcome is the payload of the dave_mls_commit_welcome opcode sent with godave.Callbacks.SendMLSCommitWelcome.
+type CommitWelcome struct {
+	Commit *MLSMessage `json:"commit"`
+	// Welcome is set if the commit adds members.
+	Welcome *Welcome `json:"welcome,omitempty"`
+}
+
+// DecodeKeyPackage decodes the key package returned by GetMarshalledKeyPackage and sent with
+// godave.Callbacks.SendMLSKeyPackage.
+func DecodeKeyPackage(data []byte) (*KeyPackage, error) {
+	r := newReader(data)
+	keyPackage := readKeyPackage(r)
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return &keyPackage, nil
+}
+
+// DecodeExternalSender decodes the external sender package passed to godave.Session.OnDaveMLSExternalSenderPackage.
+func DecodeExternalSender(data []byte) (*ExternalSender, error) {
+	r := newReader(data)
+	externalSender := readExternalSender(r)
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return &externalSender, nil
+}
+
+// DecodeMLSMessage decodes a MLSMessage, such as the commit passed to godave.Session.OnDaveMLSPrepareCommitTransition.
+func DecodeMLSMessage(data []byte) (*MLSMessage, error) {
+	r := newReader(data)
+	message := readMLSMessage(r)
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// DecodeWelcome decodes the welcome passed to godave.Session.OnDaveMLSWelcome.
+func DecodeWelcome(data []byte) (*Welcome, error) {
+	r := newReader(data)
+	welcome := readWelcome(r)
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return welcome, nil
+}
+
+// DecodeProposals decodes the proposals passed to godave.Session.OnDaveMLSProposals.
+func DecodeProposals(data []byte) (*Proposals, error) {
+	r := newReader(data)
+	proposals := &Proposals{
+		Operation: ProposalsOperation(r.uint8("operation")),
+	}
+	switch proposals.Operation {
+	case ProposalsOperationAppend:
+		proposals.Messages = []*MLSMessage{}
+		r.vector("proposal_messages", func(r *reader) {
+			proposals.Messages = append(proposals.Messages, readMLSMessage(r))
+		})
+	case ProposalsOperationRevoke:
+		proposals.Refs = []Bytes{}
+		r.vector("proposal_refs", func(r *reader) {
+			proposals.Refs = append(proposals.Refs, r.bytes("proposal_ref"))
+		})
+	default:
+		r.fail(ErrInvalidValue, "unknown proposals operation %d", proposals.Operation)
+	}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// DecodeCommitWelcome decodes the commit and optional welcome sent with godave.Callbacks.SendMLSCommitWelcome.
+func DecodeCommitWelcome(data []byte) (*CommitWelcome, error) {
+	r := newReader(data)
+	commitWelcome := &CommitWelcome{
+		Commit: readMLSMessage(r),
+	}
+	if !r.empty() {
+		commitWelcome.Welcome = readWelcome(r)
+	}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return commitWelcome, nil
+}
+
+// SplitCommitWelcome splits the payload sent with godave.Callbacks.SendMLSCommitWelcome into the commit MLSMessage
+// and the optional Welcome, as expected by godave.Session.OnDaveMLSPrepareCommitTransition and
+// godave.Session.OnDaveMLSWelcome. The welcome is not decoded.
+func SplitCommitWelcome(data []byte) (commit []byte, welcome []byte, err error) {
+	r := newReader(data)
+	readMLSMessage(r)
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	return data[:r.off], data[r.off:], nil
+}
@@ -0,0 +1,532 @@
+package mls
+
+// Extension is an extension of a KeyPackage, LeafNode or GroupContext.
+type Extension struct {
+	ExtensionType ExtensionType `json:"extension_type"`
+	Data          Bytes         `json:"data"`
+	// ExternalSenders is decoded from Data for ExtensionTypeExternalSenders.
+	ExternalSenders []ExternalSender `json:"external_senders,omitempty"`
+}
+
+func readExtensions(r *reader, field string) []Extension {
+	extensions := []Extension{}
+	r.vector(field, func(r *reader) {
+		extension := Extension{
+			ExtensionType: ExtensionType(r.uint16("extension_type")),
+			Data:          r.bytes("extension_data"),
+		}
+		if extension.ExtensionType == ExtensionTypeExternalSenders && r.err == nil {
+			dr := r.sub(extension.Data)
+			extension.ExternalSenders = []ExternalSender{}
+			dr.vector("external_senders", func(r *reader) {
+				extension.ExternalSenders = append(extension.ExternalSenders, readExternalSender(r))
+			})
+			if err := dr.done(); err != nil {
+				r.err = err
+			}
+		}
+		extensions = append(extensions, extension)
+	})
+	return extensions
+}
+
+// ExternalSender is a sender outside the group allowed to send proposals, the voice gateway in DAVE.
+type ExternalSender struct {
+	SignatureKey Bytes      `json:"signature_key"`
+	Credential   Credential `json:"credential"`
+}
+
+func readExternalSender(r *reader) ExternalSender {
+	return ExternalSender{
+		SignatureKey: r.bytes("signature_key"),
+		Credential:   readCredential(r),
+	}
+}
+
+func readCredential(r *reader) Credential {
+	credential := Credential{
+		CredentialType: CredentialType(r.uint16("credential_type")),
+	}
+	switch credential.CredentialType {
+	case CredentialTypeBasic:
+		credential.Identity = r.bytes("identity")
+	case CredentialTypeX509:
+		credential.Certificates = []Bytes{}
+		r.vector("certificates", func(r *reader) {
+			credential.Certificates = append(credential.Certificates, r.bytes("cert_data"))
+		})
+	default:
+		r.fail(ErrInvalidValue, "unknown credential type %d", credential.CredentialType)
+	}
+	return credential
+}
+
+// Capabilities are the versions, cipher suites, extensions, proposals and credentials supported by a member.
+type Capabilities struct {
+	Versions     []ProtocolVersion `json:"versions"`
+	CipherSuites []CipherSuite     `json:"cipher_suites"`
+	Extensions   []ExtensionType   `json:"extensions"`
+	Proposals    []ProposalType    `json:"proposals"`
+	Credentials  []CredentialType  `json:"credentials"`
+}
+
+func readCapabilities(r *reader) Capabilities {
+	return Capabilities{
+		Versions:     readUint16s[ProtocolVersion](r, "versions"),
+		CipherSuites: readUint16s[CipherSuite](r, "cipher_suites"),
+		Extensions:   readUint16s[ExtensionType](r, "extensions"),
+		Proposals:    readUint16s[ProposalType](r, "proposals"),
+		Credentials:  readUint16s[CredentialType](r, "credentials"),
+	}
+}
+
+// Lifetime is the validity period of a KeyPackage in seconds since the Unix epoch.
+type Lifetime struct {
+	NotBefore uint64 `json:"not_before"`
+	NotAfter  uint64 `json:"not_after"`
+}
+
+// LeafNode is a member of the ratchet tree.
+type LeafNode struct {
+	EncryptionKey  Bytes          `json:"encryption_key"`
+	SignatureKey   Bytes          `json:"signature_key"`
+	Credential     Credential     `json:"credential"`
+	Capabilities   Capabilities   `json:"capabilities"`
+	LeafNodeSource LeafNodeSource `json:"leaf_node_source"`
+	// Lifetime is set for LeafNodeSourceKeyPackage.
+	Lifetime *Lifetime `json:"lifetime,omitempty"`
+	// ParentHash is set for LeafNodeSourceCommit.
+	ParentHash Bytes       `json:"parent_hash,omitempty"`
+	Extensions []Extension `json:"extensions"`
+	Signature  Bytes       `json:"signature"`
+}
+
+func readLeafNode(r *reader) LeafNode {
+	leafNode := LeafNode{
+		EncryptionKey: r.bytes("encryption_key"),
+		SignatureKey:  r.bytes("signature_key"),
+		Credential:    readCredential(r),
+		Capabilities:  readCapabilities(r),
+	}
+	leafNode.LeafNodeSource = LeafNodeSource(r.uint8("leaf_node_source"))
+	switch leafNode.LeafNodeSource {
+	case LeafNodeSourceKeyPackage:
+		leafNode.Lifetime = &Lifetime{
+			NotBefore: r.uint64("not_before"),
+			NotAfter:  r.uint64("not_after"),
+		}
+	case LeafNodeSourceUpdate:
+	case LeafNodeSourceCommit:
+		leafNode.ParentHash = r.bytes("parent_hash")
+	default:
+		r.fail(ErrInvalidValue, "unknown leaf node source %d", leafNode.LeafNodeSource)
+		return leafNode
+	}
+	leafNode.Extensions = readExtensions(r, "extensions")
+	leafNode.Signature = r.bytes("signature")
+	return leafNode
+}
+
+// KeyPackage is the key package a client publishes to be added to a group.
+type KeyPackage struct {
+	Version     ProtocolVersion `json:"version"`
+	CipherSuite CipherSuite     `json:"cipher_suite"`
+	InitKey     Bytes           `json:"init_key"`
+	LeafNode    LeafNode        `json:"leaf_node"`
+	Extensions  []Extension     `json:"extensions"`
+	Signature   Bytes           `json:"signature"`
+}
+
+func readKeyPackage(r *reader) KeyPackage {
+	return KeyPackage{
+		Version:     ProtocolVersion(r.uint16("version")),
+		CipherSuite: CipherSuite(r.uint16("cipher_suite")),
+		InitKey:     r.bytes("init_key"),
+		LeafNode:    readLeafNode(r),
+		Extensions:  readExtensions(r, "extensions"),
+		Signature:   r.bytes("signature"),
+	}
+}
+
+// PreSharedKeyID identifies a pre-shared key.
+type PreSharedKeyID struct {
+	// PSKType is 1 for external and 2 for resumption pre-shared keys.
+	PSKType uint8 `json:"psktype"`
+	// PSKID is set for external pre-shared keys.
+	PSKID Bytes `json:"psk_id,omitempty"`
+	// Usage, PSKGroupID and PSKEpoch are set for resumption pre-shared keys.
+	Usage      uint8  `json:"usage,omitempty"`
+	PSKGroupID Bytes  `json:"psk_group_id,omitempty"`
+	PSKEpoch   uint64 `json:"psk_epoch,omitempty"`
+	PSKNonce   Bytes  `json:"psk_nonce"`
+}
+
+func readPreSharedKeyID(r *reader) PreSharedKeyID {
+	psk := PreSharedKeyID{
+		PSKType: r.uint8("psktype"),
+	}
+	switch psk.PSKType {
+	case 1:
+		psk.PSKID = r.bytes("psk_id")
+	case 2:
+		psk.Usage = r.uint8("usage")
+		psk.PSKGroupID = r.bytes("psk_group_id")
+		psk.PSKEpoch = r.uint64("psk_epoch")
+	default:
+		r.fail(ErrInvalidValue, "unknown psk type %d", psk.PSKType)
+		return psk
+	}
+	psk.PSKNonce = r.bytes("psk_nonce")
+	return psk
+}
+
+// ReInit reinitializes the group with different parameters.
+type ReInit struct {
+	GroupID     Bytes           `json:"group_id"`
+	Version     ProtocolVersion `json:"version"`
+	CipherSuite CipherSuite     `json:"cipher_suite"`
+	Extensions  []Extension     `json:"extensions"`
+}
+
+// Proposal is a proposed change to the group. Exactly one of the fields matching ProposalType is set.
+type Proposal struct {
+	ProposalType ProposalType `json:"proposal_type"`
+	// Add is the KeyPackage of the member to add.
+	Add *KeyPackage `json:"add,omitempty"`
+	// Update is the new LeafNode of the sender.
+	Update *LeafNode `json:"update,omitempty"`
+	// Remove is the leaf index of the member to remove.
+	Remove       *uint32         `json:"remove,omitempty"`
+	PreSharedKey *PreSharedKeyID `json:"psk,omitempty"`
+	ReInit       *ReInit         `json:"reinit,omitempty"`
+	// ExternalInit is the KEM output of an external commit.
+	ExternalInit           Bytes       `json:"external_init,omitempty"`
+	GroupContextExtensions []Extension `json:"group_context_extensions,omitempty"`
+}
+
+func readProposal(r *reader) *Proposal {
+	proposal := &Proposal{
+		ProposalType: ProposalType(r.uint16("proposal_type")),
+	}
+	switch proposal.ProposalType {
+	case ProposalTypeAdd:
+		keyPackage := readKeyPackage(r)
+		proposal.Add = &keyPackage
+	case ProposalTypeUpdate:
+		leafNode := readLeafNode(r)
+		proposal.Update = &leafNode
+	case ProposalTypeRemove:
+		removed := r.uint32("removed")
+		proposal.Remove = &removed
+	case ProposalTypePSK:
+		psk := readPreSharedKeyID(r)
+		proposal.PreSharedKey = &psk
+	case ProposalTypeReInit:
+		proposal.ReInit = &ReInit{
+			GroupID:     r.bytes("group_id"),
+			Version:     ProtocolVersion(r.uint16("version")),
+			CipherSuite: CipherSuite(r.uint16("cipher_suite")),
+			Extensions:  readExtensions(r, "extensions"),
+		}
+	case ProposalTypeExternalInit:
+		proposal.ExternalInit = r.bytes("kem_output")
+	case ProposalTypeGroupContextExtensions:
+		proposal.GroupContextExtensions = readExtensions(r, "extensions")
+	default:
+		// proposals are not length-prefixed, so unknown proposals can't be skipped
+		r.fail(ErrInvalidValue, "unknown proposal type %d", proposal.ProposalType)
+	}
+	return proposal
+}
+
+// ProposalOrRef is a proposal of a Commit, either inline or referenced by its hash.
+type ProposalOrRef struct {
+	Proposal  *Proposal `json:"proposal,omitempty"`
+	Reference Bytes     `json:"reference,omitempty"`
+}
+
+func readProposalOrRef(r *reader) ProposalOrRef {
+	var proposalOrRef ProposalOrRef
+	switch typ := r.uint8("proposal_or_ref_type"); typ {
+	case 1:
+		proposalOrRef.Proposal = readProposal(r)
+	case 2:
+		proposalOrRef.Reference = r.bytes("reference")
+	default:
+		r.fail(ErrInvalidValue, "unknown proposal or ref type %d", typ)
+	}
+	return proposalOrRef
+}
+
+// HPKECiphertext is a value encrypted with HPKE.
+type HPKECiphertext struct {
+	KEMOutput  Bytes `json:"kem_output"`
+	Ciphertext Bytes `json:"ciphertext"`
+}
+
+func readHPKECiphertext(r *reader) HPKECiphertext {
+	return HPKECiphertext{
+		KEMOutput:  r.bytes("kem_output"),
+		Ciphertext: r.bytes("ciphertext"),
+	}
+}
+
+// UpdatePathNode is a node on the direct path of the committer.
+type UpdatePathNode struct {
+	EncryptionKey       Bytes            `json:"encryption_key"`
+	EncryptedPathSecret []HPKECiphertext `json:"encrypted_path_secret"`
+}
+
+// UpdatePath is the new LeafNode and path secrets of the committer.
+type UpdatePath struct {
+	LeafNode LeafNode         `json:"leaf_node"`
+	Nodes    []UpdatePathNode `json:"nodes"`
+}
+
+func readUpdatePath(r *reader) *UpdatePath {
+	path := &UpdatePath{
+		LeafNode: readLeafNode(r),
+		Nodes:    []UpdatePathNode{},
+	}
+	r.vector("nodes", func(r *reader) {
+		node := UpdatePathNode{
+			EncryptionKey:       r.bytes("encryption_key"),
+			EncryptedPathSecret: []HPKECiphertext{},
+		}
+		r.vector("encrypted_path_secret", func(r *reader) {
+			node.EncryptedPathSecret = append(node.EncryptedPathSecret, readHPKECiphertext(r))
+		})
+		path.Nodes = append(path.Nodes, node)
+	})
+	return path
+}
+
+// Commit applies proposals to the group and starts a new epoch.
+type Commit struct {
+	Proposals []ProposalOrRef `json:"proposals"`
+	Path      *UpdatePath     `json:"path,omitempty"`
+}
+
+func readCommit(r *reader) *Commit {
+	commit := &Commit{
+		Proposals: []ProposalOrRef{},
+	}
+	r.vector("proposals", func(r *reader) {
+		commit.Proposals = append(commit.Proposals, readProposalOrRef(r))
+	})
+	if r.optional("path") {
+		commit.Path = readUpdatePath(r)
+	}
+	return commit
+}
+
+// Sender is the sender of a FramedContent.
+type Sender struct {
+	SenderType SenderType `json:"sender_type"`
+	// LeafIndex is set for SenderTypeMember.
+	LeafIndex *uint32 `json:"leaf_index,omitempty"`
+	// SenderIndex is the index in the external senders extension, set for SenderTypeExternal.
+	SenderIndex *uint32 `json:"sender_index,omitempty"`
+}
+
+func readSender(r *reader) Sender {
+	sender := Sender{
+		SenderType: SenderType(r.uint8("sender_type")),
+	}
+	switch sender.SenderType {
+	case SenderTypeMember:
+		index := r.uint32("leaf_index")
+		sender.LeafIndex = &index
+	case SenderTypeExternal:
+		index := r.uint32("sender_index")
+		sender.SenderIndex = &index
+	case SenderTypeNewMemberProposal, SenderTypeNewMemberCommit:
+	default:
+		r.fail(ErrInvalidValue, "unknown sender type %d", sender.SenderType)
+	}
+	return sender
+}
+
+// FramedContent is the content of a PublicMessage. Exactly one of the fields matching ContentType is set.
+type FramedContent struct {
+	GroupID           Bytes       `json:"group_id"`
+	Epoch             uint64      `json:"epoch"`
+	Sender            Sender      `json:"sender"`
+	AuthenticatedData Bytes       `json:"authenticated_data"`
+	ContentType       ContentType `json:"content_type"`
+	ApplicationData   Bytes       `json:"application_data,omitempty"`
+	Proposal          *Proposal   `json:"proposal,omitempty"`
+	Commit            *Commit     `json:"commit,omitempty"`
+}
+
+// PublicMessage is a signed but unencrypted handshake message.
+type PublicMessage struct {
+	Content   FramedContent `json:"content"`
+	Signature Bytes         `json:"signature"`
+	// ConfirmationTag is set for commits.
+	ConfirmationTag Bytes `json:"confirmation_tag,omitempty"`
+	// MembershipTag is set for messages sent by members.
+	MembershipTag Bytes `json:"membership_tag,omitempty"`
+}
+
+func readPublicMessage(r *reader) *PublicMessage {
+	content := FramedContent{
+		GroupID:           r.bytes("group_id"),
+		Epoch:             r.uint64("epoch"),
+		Sender:            readSender(r),
+		AuthenticatedData: r.bytes("authenticated_data"),
+		ContentType:       ContentType(r.uint8("content_type")),
+	}
+	if r.err != nil {
+		return nil
+	}
+	switch content.ContentType {
+	case ContentTypeApplication:
+		content.ApplicationData = r.bytes("application_data")
+	case ContentTypeProposal:
+		content.Proposal = readProposal(r)
+	case ContentTypeCommit:
+		content.Commit = readCommit(r)
+	default:
+		r.fail(ErrInvalidValue, "unknown content type %d", content.ContentType)
+		return nil
+	}
+
+	message := &PublicMessage{
+		Content:   content,
+		Signature: r.bytes("signature"),
+	}
+	if content.ContentType == ContentTypeCommit {
+		message.ConfirmationTag = r.bytes("confirmation_tag")
+	}
+	if content.Sender.SenderType == SenderTypeMember {
+		message.MembershipTag = r.bytes("membership_tag")
+	}
+	return message
+}
+
+// PrivateMessage is an encrypted message. Its content can't be decoded without the group secrets.
+type PrivateMessage struct {
+	GroupID             Bytes       `json:"group_id"`
+	Epoch               uint64      `json:"epoch"`
+	ContentType         ContentType `json:"content_type"`
+	AuthenticatedData   Bytes       `json:"authenticated_data"`
+	EncryptedSenderData Bytes       `json:"encrypted_sender_data"`
+	Ciphertext          Bytes       `json:"ciphertext"`
+}
+
+func readPrivateMessage(r *reader) *PrivateMessage {
+	return &PrivateMessage{
+		GroupID:             r.bytes("group_id"),
+		Epoch:               r.uint64("epoch"),
+		ContentType:         ContentType(r.uint8("content_type")),
+		AuthenticatedData:   r.bytes("authenticated_data"),
+		EncryptedSenderData: r.bytes("encrypted_sender_data"),
+		Ciphertext:          r.bytes("ciphertext"),
+	}
+}
+
+// EncryptedGroupSecrets are the group secrets encrypted to a new member.
+type EncryptedGroupSecrets struct {
+	// NewMember is the reference of the KeyPackage of the new member.
+	NewMember             Bytes          `json:"new_member"`
+	EncryptedGroupSecrets HPKECiphertext `json:"encrypted_group_secrets"`
+}
+
+// Welcome adds new members to a group.
+type Welcome struct {
+	CipherSuite        CipherSuite             `json:"cipher_suite"`
+	Secrets            []EncryptedGroupSecrets `json:"secrets"`
+	EncryptedGroupInfo Bytes                   `json:"encrypted_group_info"`
+}
+
+func readWelcome(r *reader) *Welcome {
+	welcome := &Welcome{
+		CipherSuite: CipherSuite(r.uint16("cipher_suite")),
+		Secrets:     []EncryptedGroupSecrets{},
+	}
+	r.vector("secrets", func(r *reader) {
+		welcome.Secrets = append(welcome.Secrets, EncryptedGroupSecrets{
+			NewMember:             r.bytes("new_member"),
+			EncryptedGroupSecrets: readHPKECiphertext(r),
+		})
+	})
+	welcome.EncryptedGroupInfo = r.bytes("encrypted_group_info")
+	return welcome
+}
+
+// GroupContext is the state of a group in an epoch.
+type GroupContext struct {
+	Version                 ProtocolVersion `json:"version"`
+	CipherSuite             CipherSuite     `json:"cipher_suite"`
+	GroupID                 Bytes           `json:"group_id"`
+	Epoch                   uint64          `json:"epoch"`
+	TreeHash                Bytes           `json:"tree_hash"`
+	ConfirmedTranscriptHash Bytes           `json:"confirmed_transcript_hash"`
+	Extensions              []Extension     `json:"extensions"`
+}
+
+// GroupInfo is the information needed to join a group.
+type GroupInfo struct {
+	GroupContext    GroupContext `json:"group_context"`
+	Extensions      []Extension  `json:"extensions"`
+	ConfirmationTag Bytes        `json:"confirmation_tag"`
+	Signer          uint32       `json:"signer"`
+	Signature       Bytes        `json:"signature"`
+}
+
+func readGroupInfo(r *reader) *GroupInfo {
+	return &GroupInfo{
+		GroupContext: GroupContext{
+			Version:                 ProtocolVersion(r.uint16("version")),
+			CipherSuite:             CipherSuite(r.uint16("cipher_suite")),
+			GroupID:                 r.bytes("group_id"),
+			Epoch:                   r.uint64("epoch"),
+			TreeHash:                r.bytes("tree_hash"),
+			ConfirmedTranscriptHash: r.bytes("confirmed_transcript_hash"),
+			Extensions:              readExtensions(r, "extensions"),
+		},
+		Extensions:      readExtensions(r, "extensions"),
+		ConfirmationTag: r.bytes("confirmation_tag"),
+		Signer:          r.uint32("signer"),
+		Signature:       r.bytes("signature"),
+	}
+}
+
+// MLSMessage wraps the messages exchanged by MLS clients. Exactly one of the fields matching WireFormat is set.
+type MLSMessage struct {
+	Version        ProtocolVersion `json:"version"`
+	WireFormat     WireFormat      `json:"wire_format"`
+	PublicMessage  *PublicMessage  `json:"public_message,omitempty"`
+	PrivateMessage *PrivateMessage `json:"private_message,omitempty"`
+	Welcome        *Welcome        `json:"welcome,omitempty"`
+	GroupInfo      *GroupInfo      `json:"group_info,omitempty"`
+	KeyPackage     *KeyPackage     `json:"key_package,omitempty"`
+}
+
+func readMLSMessage(r *reader) *MLSMessage {
+	message := &MLSMessage{
+		Version: ProtocolVersion(r.uint16("version")),
+	}
+	if r.err == nil && message.Version != ProtocolVersionMLS10 {
+		r.fail(ErrInvalidValue, "unsupported version %d", message.Version)
+		return message
+	}
+	message.WireFormat = WireFormat(r.uint16("wire_format"))
+	switch message.WireFormat {
+	case WireFormatPublicMessage:
+		message.PublicMessage = readPublicMessage(r)
+	case WireFormatPrivateMessage:
+		message.PrivateMessage = readPrivateMessage(r)
+	case WireFormatWelcome:
+		message.Welcome = readWelcome(r)
+	case WireFormatGroupInfo:
+		message.GroupInfo = readGroupInfo(r)
+	case WireFormatKeyPackage:
+		keyPackage := readKeyPackage(r)
+		message.KeyPackage = &keyPackage
+	default:
+		r.fail(ErrInvalidValue, "unknown wire format %d", message.WireFormat)
+	}
+	return message
+}
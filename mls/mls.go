@@ -0,0 +1,282 @@
+// Package mls decodes the MLS structures exchanged by DAVE sessions, as defined by RFC 9420.
+//
+// It decodes the payloads passed through godave.Session and godave.Callbacks, such as key packages, proposals,
+// commits and welcomes, into Go values which marshal to readable JSON, to debug handshake failures from logged payloads.
+// The decoder does not verify signatures or decrypt anything.
+package mls
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/disgoorg/godave"
+)
+
+// Bytes is an opaque value. It is rendered as hex.
+type Bytes []byte
+
+func (b Bytes) String() string {
+	return hex.EncodeToString(b)
+}
+
+func (b Bytes) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func enumName[T ~uint8 | ~uint16](names map[T]string, v T) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", v)
+}
+
+// ProtocolVersion is the MLS protocol version.
+type ProtocolVersion uint16
+
+const ProtocolVersionMLS10 ProtocolVersion = 1
+
+func (v ProtocolVersion) String() string {
+	return enumName(map[ProtocolVersion]string{ProtocolVersionMLS10: "mls10"}, v)
+}
+
+func (v ProtocolVersion) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// CipherSuite is a MLS cipher suite. DAVE uses CipherSuiteP256.
+type CipherSuite uint16
+
+const (
+	CipherSuiteX25519AES128 CipherSuite = iota + 1
+	CipherSuiteP256
+	CipherSuiteX25519ChaCha20
+	CipherSuiteX448AES256
+	CipherSuiteP521
+	CipherSuiteX448ChaCha20
+	CipherSuiteP384
+)
+
+var cipherSuiteNames = map[CipherSuite]string{
+	CipherSuiteX25519AES128:   "MLS_128_DHKEMX25519_AES128GCM_SHA256_Ed25519",
+	CipherSuiteP256:           "MLS_128_DHKEMP256_AES128GCM_SHA256_P256",
+	CipherSuiteX25519ChaCha20: "MLS_128_DHKEMX25519_CHACHA20POLY1305_SHA256_Ed25519",
+	CipherSuiteX448AES256:     "MLS_256_DHKEMX448_AES256GCM_SHA512_Ed448",
+	CipherSuiteP521:           "MLS_256_DHKEMP521_AES256GCM_SHA512_P521",
+	CipherSuiteX448ChaCha20:   "MLS_256_DHKEMX448_CHACHA20POLY1305_SHA512_Ed448",
+	CipherSuiteP384:           "MLS_256_DHKEMP384_AES256GCM_SHA384_P384",
+}
+
+func (c CipherSuite) String() string {
+	return enumName(cipherSuiteNames, c)
+}
+
+func (c CipherSuite) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// WireFormat is the format of a MLSMessage.
+type WireFormat uint16
+
+const (
+	WireFormatPublicMessage WireFormat = iota + 1
+	WireFormatPrivateMessage
+	WireFormatWelcome
+	WireFormatGroupInfo
+	WireFormatKeyPackage
+)
+
+var wireFormatNames = map[WireFormat]string{
+	WireFormatPublicMessage:  "mls_public_message",
+	WireFormatPrivateMessage: "mls_private_message",
+	WireFormatWelcome:        "mls_welcome",
+	WireFormatGroupInfo:      "mls_group_info",
+	WireFormatKeyPackage:     "mls_key_package",
+}
+
+func (w WireFormat) String() string {
+	return enumName(wireFormatNames, w)
+}
+
+func (w WireFormat) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+// SenderType is the type of the sender of a FramedContent.
+type SenderType uint8
+
+const (
+	SenderTypeMember SenderType = iota + 1
+	SenderTypeExternal
+	SenderTypeNewMemberProposal
+	SenderTypeNewMemberCommit
+)
+
+var senderTypeNames = map[SenderType]string{
+	SenderTypeMember:            "member",
+	SenderTypeExternal:          "external",
+	SenderTypeNewMemberProposal: "new_member_proposal",
+	SenderTypeNewMemberCommit:   "new_member_commit",
+}
+
+func (s SenderType) String() string {
+	return enumName(senderTypeNames, s)
+}
+
+func (s SenderType) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ContentType is the type of the content of a FramedContent.
+type ContentType uint8
+
+const (
+	ContentTypeApplication ContentType = iota + 1
+	ContentTypeProposal
+	ContentTypeCommit
+)
+
+var contentTypeNames = map[ContentType]string{
+	ContentTypeApplication: "application",
+	ContentTypeProposal:    "proposal",
+	ContentTypeCommit:      "commit",
+}
+
+func (c ContentType) String() string {
+	return enumName(contentTypeNames, c)
+}
+
+func (c ContentType) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// ProposalType is the type of a Proposal.
+type ProposalType uint16
+
+const (
+	ProposalTypeAdd ProposalType = iota + 1
+	ProposalTypeUpdate
+	ProposalTypeRemove
+	ProposalTypePSK
+	ProposalTypeReInit
+	ProposalTypeExternalInit
+	ProposalTypeGroupContextExtensions
+)
+
+var proposalTypeNames = map[ProposalType]string{
+	ProposalTypeAdd:                    "add",
+	ProposalTypeUpdate:                 "update",
+	ProposalTypeRemove:                 "remove",
+	ProposalTypePSK:                    "psk",
+	ProposalTypeReInit:                 "reinit",
+	ProposalTypeExternalInit:           "external_init",
+	ProposalTypeGroupContextExtensions: "group_context_extensions",
+}
+
+func (p ProposalType) String() string {
+	return enumName(proposalTypeNames, p)
+}
+
+func (p ProposalType) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// CredentialType is the type of a Credential. DAVE uses CredentialTypeBasic.
+type CredentialType uint16
+
+const (
+	CredentialTypeBasic CredentialType = iota + 1
+	CredentialTypeX509
+)
+
+var credentialTypeNames = map[CredentialType]string{
+	CredentialTypeBasic: "basic",
+	CredentialTypeX509:  "x509",
+}
+
+func (c CredentialType) String() string {
+	return enumName(credentialTypeNames, c)
+}
+
+func (c CredentialType) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// ExtensionType is the type of an Extension.
+type ExtensionType uint16
+
+const (
+	ExtensionTypeApplicationID ExtensionType = iota + 1
+	ExtensionTypeRatchetTree
+	ExtensionTypeRequiredCapabilities
+	ExtensionTypeExternalPub
+	ExtensionTypeExternalSenders
+)
+
+var extensionTypeNames = map[ExtensionType]string{
+	ExtensionTypeApplicationID:        "application_id",
+	ExtensionTypeRatchetTree:          "ratchet_tree",
+	ExtensionTypeRequiredCapabilities: "required_capabilities",
+	ExtensionTypeExternalPub:          "external_pub",
+	ExtensionTypeExternalSenders:      "external_senders",
+}
+
+func (e ExtensionType) String() string {
+	return enumName(extensionTypeNames, e)
+}
+
+func (e ExtensionType) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// LeafNodeSource is the reason a LeafNode was created.
+type LeafNodeSource uint8
+
+const (
+	LeafNodeSourceKeyPackage LeafNodeSource = iota + 1
+	LeafNodeSourceUpdate
+	LeafNodeSourceCommit
+)
+
+var leafNodeSourceNames = map[LeafNodeSource]string{
+	LeafNodeSourceKeyPackage: "key_package",
+	LeafNodeSourceUpdate:     "update",
+	LeafNodeSourceCommit:     "commit",
+}
+
+func (l LeafNodeSource) String() string {
+	return enumName(leafNodeSourceNames, l)
+}
+
+func (l LeafNodeSource) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// Credential identifies a member of the group.
+type Credential struct {
+	CredentialType CredentialType `json:"credential_type"`
+	// Identity is the identity of a basic credential. DAVE uses the big-endian user ID.
+	Identity Bytes `json:"identity,omitempty"`
+	// Certificates are the certificates of a x509 credential.
+	Certificates []Bytes `json:"certificates,omitempty"`
+}
+
+// UserID returns the user ID of a DAVE basic credential.
+func (c Credential) UserID() (godave.UserID, bool) {
+	if c.CredentialType != CredentialTypeBasic || len(c.Identity) != 8 {
+		return "", false
+	}
+	return godave.UserID(strconv.FormatUint(binary.BigEndian.Uint64(c.Identity), 10)), true
+}
+
+func (c Credential) MarshalJSON() ([]byte, error) {
+	type credential Credential
+	v := struct {
+		credential
+		UserID godave.UserID `json:"user_id,omitempty"`
+	}{credential: credential(c)}
+	v.UserID, _ = c.UserID()
+	return json.Marshal(v)
+}
@@ -0,0 +1,187 @@
+package mls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func appendVector(b []byte, data []byte) []byte {
+	if len(data) < 1<<6 {
+		b = append(b, byte(len(data)))
+	} else {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(data))|0x4000)
+	}
+	return append(b, data...)
+}
+
+func appendUint16s(b []byte, values ...uint16) []byte {
+	var data []byte
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint16(data, v)
+	}
+	return appendVector(b, data)
+}
+
+func appendCredential(b []byte, userID uint64) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(CredentialTypeBasic))
+	return appendVector(b, binary.BigEndian.AppendUint64(nil, userID))
+}
+
+func testKeyPackage(userID uint64) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(ProtocolVersionMLS10))
+	b = binary.BigEndian.AppendUint16(b, uint16(CipherSuiteP256))
+	b = appendVector(b, bytes.Repeat([]byte{1}, 65)) // init_key
+
+	// LeafNode
+	b = appendVector(b, bytes.Repeat([]byte{2}, 65)) // encryption_key
+	b = appendVector(b, bytes.Repeat([]byte{3}, 65)) // signature_key
+	b = appendCredential(b, userID)
+	b = appendUint16s(b, uint16(ProtocolVersionMLS10))
+	b = appendUint16s(b, uint16(CipherSuiteP256))
+	b = appendUint16s(b)
+	b = appendUint16s(b)
+	b = appendUint16s(b, uint16(CredentialTypeBasic))
+	b = append(b, byte(LeafNodeSourceKeyPackage))
+	b = binary.BigEndian.AppendUint64(b, 0)
+	b = binary.BigEndian.AppendUint64(b, 1<<62)
+	b = appendVector(b, nil)                         // extensions
+	b = appendVector(b, bytes.Repeat([]byte{4}, 72)) // signature
+
+	b = appendVector(b, nil)                            // extensions
+	return appendVector(b, bytes.Repeat([]byte{5}, 72)) // signature
+}
+
+func testPublicMessage(senderType SenderType, contentType ContentType, content []byte) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(ProtocolVersionMLS10))
+	b = binary.BigEndian.AppendUint16(b, uint16(WireFormatPublicMessage))
+	b = appendVector(b, []byte{0, 0, 0, 0, 0, 0, 0, 1}) // group_id
+	b = binary.BigEndian.AppendUint64(b, 3)
+	b = append(b, byte(senderType))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = appendVector(b, nil) // authenticated_data
+	b = append(b, byte(contentType))
+	b = append(b, content...)
+	b = appendVector(b, bytes.Repeat([]byte{6}, 72)) // signature
+	if contentType == ContentTypeCommit {
+		b = appendVector(b, bytes.Repeat([]byte{7}, 32))
+	}
+	if senderType == SenderTypeMember {
+		b = appendVector(b, bytes.Repeat([]byte{8}, 32))
+	}
+	return b
+}
+
+func testWelcome() []byte {
+	var secret []byte
+	secret = appendVector(secret, bytes.Repeat([]byte{9}, 32))  // new_member
+	secret = appendVector(secret, bytes.Repeat([]byte{10}, 65)) // kem_output
+	secret = appendVector(secret, bytes.Repeat([]byte{11}, 80)) // ciphertext
+
+	b := binary.BigEndian.AppendUint16(nil, uint16(CipherSuiteP256))
+	b = appendVector(b, secret)
+	return appendVector(b, bytes.Repeat([]byte{12}, 100))
+}
+
+func TestDecodeKeyPackage(t *testing.T) {
+	keyPackage, err := DecodeKeyPackage(testKeyPackage(1234))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyPackage.CipherSuite != CipherSuiteP256 {
+		t.Errorf("expected cipher suite %s, got %s", CipherSuiteP256, keyPackage.CipherSuite)
+	}
+	if userID, ok := keyPackage.LeafNode.Credential.UserID(); !ok || userID != "1234" {
+		t.Errorf("expected user ID 1234, got %q", userID)
+	}
+	if keyPackage.LeafNode.Lifetime == nil || keyPackage.LeafNode.Lifetime.NotAfter != 1<<62 {
+		t.Errorf("expected lifetime, got %+v", keyPackage.LeafNode.Lifetime)
+	}
+
+	data, err := json.Marshal(keyPackage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"cipher_suite":"MLS_128_DHKEMP256_AES128GCM_SHA256_P256"`, `"user_id":"1234"`, `"leaf_node_source":"key_package"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected JSON to contain %s, got %s", want, data)
+		}
+	}
+}
+
+func TestDecodeProposals(t *testing.T) {
+	add := testPublicMessage(SenderTypeExternal, ContentTypeProposal, append(binary.BigEndian.AppendUint16(nil, uint16(ProposalTypeAdd)), testKeyPackage(1)...))
+	remove := testPublicMessage(SenderTypeExternal, ContentTypeProposal, binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint16(nil, uint16(ProposalTypeRemove)), 2))
+
+	proposals, err := DecodeProposals(appendVector([]byte{byte(ProposalsOperationAppend)}, append(add, remove...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(proposals.Messages))
+	}
+	if proposal := proposals.Messages[0].PublicMessage.Content.Proposal; proposal.Add == nil {
+		t.Errorf("expected add proposal, got %s", proposal.ProposalType)
+	}
+	if proposal := proposals.Messages[1].PublicMessage.Content.Proposal; proposal.Remove == nil || *proposal.Remove != 2 {
+		t.Errorf("expected remove proposal of leaf 2, got %+v", proposal)
+	}
+
+	proposals, err = DecodeProposals(appendVector([]byte{byte(ProposalsOperationRevoke)}, appendVector(nil, []byte{1, 2})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals.Refs) != 1 || !bytes.Equal(proposals.Refs[0], []byte{1, 2}) {
+		t.Errorf("expected revoked ref 0102, got %v", proposals.Refs)
+	}
+}
+
+func TestDecodeCommitWelcome(t *testing.T) {
+	var content []byte
+	content = appendVector(content, append([]byte{2}, appendVector(nil, bytes.Repeat([]byte{1}, 32))...)) // proposals
+	content = append(content, 0)                                                                          // no path
+	commit := testPublicMessage(SenderTypeMember, ContentTypeCommit, content)
+	welcome := testWelcome()
+
+	commitWelcome, err := DecodeCommitWelcome(append(commit, welcome...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := commitWelcome.Commit.PublicMessage.Content.Commit; len(c.Proposals) != 1 || c.Path != nil {
+		t.Errorf("expected commit with one proposal reference, got %+v", c)
+	}
+	if commitWelcome.Welcome == nil || len(commitWelcome.Welcome.Secrets) != 1 {
+		t.Errorf("expected welcome for one member, got %+v", commitWelcome.Welcome)
+	}
+
+	gotCommit, gotWelcome, err := SplitCommitWelcome(append(commit, welcome...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotCommit, commit) {
+		t.Errorf("expected commit of %d bytes, got %d", len(commit), len(gotCommit))
+	}
+	if !bytes.Equal(gotWelcome, welcome) {
+		t.Errorf("expected welcome of %d bytes, got %d", len(welcome), len(gotWelcome))
+	}
+
+	if _, _, err = SplitCommitWelcome(commit[:len(commit)-1]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected %v, got %v", ErrTruncated, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	keyPackage := testKeyPackage(1)
+	if _, err := DecodeKeyPackage(keyPackage[:len(keyPackage)-1]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected %v, got %v", ErrTruncated, err)
+	}
+	if _, err := DecodeKeyPackage(append(keyPackage, 0)); !errors.Is(err, ErrTrailingData) {
+		t.Errorf("expected %v, got %v", ErrTrailingData, err)
+	}
+	if _, err := DecodeMLSMessage([]byte{0, 1, 0, 42}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("expected %v, got %v", ErrInvalidValue, err)
+	}
+}
@@ -0,0 +1,148 @@
+package mls
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTruncated    = errors.New("mls: truncated data")
+	ErrTrailingData = errors.New("mls: trailing data")
+	ErrInvalidValue = errors.New("mls: invalid value")
+)
+
+// reader reads the TLS presentation language encoding of MLS structures, see RFC 9420 section 2.1.
+// The first error is kept in err and all following reads return zero values.
+type reader struct {
+	data []byte
+	off  int
+	err  error
+	// base is the offset of data in the decoded message, used in errors
+	base int
+}
+
+func newReader(data []byte) *reader {
+	return &reader{data: data}
+}
+
+func (r *reader) fail(err error, format string, a ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s at offset %d", err, fmt.Sprintf(format, a...), r.base+r.off)
+	}
+}
+
+func (r *reader) empty() bool {
+	return r.err != nil || r.off == len(r.data)
+}
+
+// done returns the first error, or ErrTrailingData if not all data was read.
+func (r *reader) done() error {
+	if r.err == nil && r.off != len(r.data) {
+		r.fail(ErrTrailingData, "%d bytes", len(r.data)-r.off)
+	}
+	return r.err
+}
+
+func (r *reader) next(n int, field string) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data)-r.off < n {
+		r.fail(ErrTruncated, "%s needs %d bytes, %d left", field, n, len(r.data)-r.off)
+		return nil
+	}
+	b := r.data[r.off : r.off+n : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *reader) uint8(field string) uint8 {
+	if b := r.next(1, field); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16(field string) uint16 {
+	if b := r.next(2, field); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32(field string) uint32 {
+	if b := r.next(4, field); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64(field string) uint64 {
+	if b := r.next(8, field); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// varint reads a variable-length integer as defined in RFC 9000 section 16, used for the length of vectors.
+func (r *reader) varint(field string) int {
+	first := r.uint8(field)
+	length := 1 << (first >> 6)
+	if r.err == nil && length == 8 {
+		r.fail(ErrInvalidValue, "%s has an invalid length prefix", field)
+		return 0
+	}
+	v := int(first & 0x3f)
+	for _, b := range r.next(length-1, field) {
+		v = v<<8 | int(b)
+	}
+	return v
+}
+
+// bytes reads an opaque vector.
+func (r *reader) bytes(field string) Bytes {
+	return r.next(r.varint(field), field)
+}
+
+// sub returns a reader of data, which must have just been read from r.
+func (r *reader) sub(data []byte) *reader {
+	return &reader{data: data, base: r.base + r.off - len(data)}
+}
+
+// vector reads a vector of elements decoded by read from a reader limited to the vector.
+func (r *reader) vector(field string, read func(r *reader)) {
+	data := r.bytes(field)
+	if r.err != nil {
+		return
+	}
+	vr := r.sub(data)
+	for !vr.empty() {
+		read(vr)
+	}
+	if vr.err != nil {
+		r.err = vr.err
+	}
+}
+
+// optional reads the presence byte of an optional value.
+func (r *reader) optional(field string) bool {
+	switch present := r.uint8(field); present {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		r.fail(ErrInvalidValue, "%s has presence byte %d", field, present)
+		return false
+	}
+}
+
+// readUint16s reads a vector of uint16 values.
+func readUint16s[T ~uint16](r *reader, field string) []T {
+	values := []T{}
+	r.vector(field, func(r *reader) {
+		values = append(values, T(r.uint16(field)))
+	})
+	return values
+}
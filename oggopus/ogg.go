@@ -0,0 +1,78 @@
+package oggopus
+
+import (
+	"encoding/binary"
+)
+
+// Ogg page framing, see RFC 3533.
+
+const (
+	pageHeaderSize      = 27
+	maxSegments         = 255
+	maxSegmentSize      = 255
+	headerContinued     = 0x01
+	headerBeginOfStream = 0x02
+	headerEndOfStream   = 0x04
+)
+
+var crcTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// crc returns the checksum of an Ogg page, it differs from the IEEE CRC-32 by using no bit reflection and no final xor.
+func crc(crc uint32, b []byte) uint32 {
+	for _, c := range b {
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^c]
+	}
+	return crc
+}
+
+// segments returns the number of lacing values of a packet.
+func segments(packet []byte) int {
+	return len(packet)/maxSegmentSize + 1
+}
+
+// appendPage appends an Ogg page containing the complete packets.
+func appendPage(b []byte, headerType byte, granule uint64, serial uint32, sequence uint32, packets [][]byte) []byte {
+	start := len(b)
+	b = append(b, "OggS"...)
+	b = append(b, 0, headerType)
+	b = binary.LittleEndian.AppendUint64(b, granule)
+	b = binary.LittleEndian.AppendUint32(b, serial)
+	b = binary.LittleEndian.AppendUint32(b, sequence)
+	// checksum, computed over the page with this field zeroed
+	b = append(b, 0, 0, 0, 0)
+
+	count := 0
+	for _, packet := range packets {
+		count += segments(packet)
+	}
+	b = append(b, byte(count))
+	for _, packet := range packets {
+		for n := len(packet); ; n -= maxSegmentSize {
+			if n < maxSegmentSize {
+				b = append(b, byte(n))
+				break
+			}
+			b = append(b, maxSegmentSize)
+		}
+	}
+	for _, packet := range packets {
+		b = append(b, packet...)
+	}
+
+	binary.LittleEndian.PutUint32(b[start+22:], crc(0, b[start:]))
+	return b
+}
@@ -0,0 +1,177 @@
+// Package oggopus reads and writes Opus packets in Ogg containers as specified by RFC 7845.
+//
+// Only streams with channel mapping family 0, which covers the mono and stereo audio of Discord voice connections,
+// are supported. Granule positions count samples at 48 kHz, the sample rate of Opus and of the RTP timestamps of
+// Discord voice packets.
+package oggopus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// SampleRate is the rate of granule positions and packet durations.
+const SampleRate = 48000
+
+// SilenceFrame is the Opus frame of 20ms silence sent by Discord clients when they stop speaking.
+var SilenceFrame = []byte{0xF8, 0xFF, 0xFE}
+
+// SilenceFrameDuration is the duration of SilenceFrame in samples.
+const SilenceFrameDuration = SampleRate / 50
+
+var (
+	ErrInvalidPacket = errors.New("oggopus: invalid opus packet")
+	ErrInvalidStream = errors.New("oggopus: invalid ogg opus stream")
+	ErrUnsupported   = errors.New("oggopus: unsupported ogg opus stream")
+	ErrClosed        = errors.New("oggopus: writer closed")
+)
+
+// Head is the identification header of an Ogg Opus stream.
+type Head struct {
+	// Channels is the number of output channels, 1 or 2.
+	Channels uint8
+	// PreSkip is the number of samples to discard from the decoder output at the start of the stream.
+	PreSkip uint16
+	// InputSampleRate is the sample rate of the original input, informational only.
+	InputSampleRate uint32
+	// OutputGain is the gain to apply to the decoder output in Q7.8 dB.
+	OutputGain int16
+}
+
+const headSize = 19
+
+func (h Head) marshal() []byte {
+	b := append(make([]byte, 0, headSize), "OpusHead"...)
+	b = append(b, 1, h.Channels)
+	b = binary.LittleEndian.AppendUint16(b, h.PreSkip)
+	b = binary.LittleEndian.AppendUint32(b, h.InputSampleRate)
+	b = binary.LittleEndian.AppendUint16(b, uint16(h.OutputGain))
+	// channel mapping family 0
+	return append(b, 0)
+}
+
+func parseHead(packet []byte) (Head, error) {
+	if len(packet) < headSize || string(packet[:8]) != "OpusHead" {
+		return Head{}, fmt.Errorf("%w: missing OpusHead", ErrInvalidStream)
+	}
+	// the major version is in the upper four bits, minor versions are compatible
+	if packet[8]>>4 != 0 {
+		return Head{}, fmt.Errorf("%w: version %d", ErrUnsupported, packet[8])
+	}
+	if packet[18] != 0 {
+		return Head{}, fmt.Errorf("%w: channel mapping family %d", ErrUnsupported, packet[18])
+	}
+	h := Head{
+		Channels:        packet[9],
+		PreSkip:         binary.LittleEndian.Uint16(packet[10:]),
+		InputSampleRate: binary.LittleEndian.Uint32(packet[12:]),
+		OutputGain:      int16(binary.LittleEndian.Uint16(packet[16:])),
+	}
+	if h.Channels == 0 || h.Channels > 2 {
+		return Head{}, fmt.Errorf("%w: %d channels", ErrInvalidStream, h.Channels)
+	}
+	return h, nil
+}
+
+// Tags is the comment header of an Ogg Opus stream.
+type Tags struct {
+	// Vendor identifies the program which wrote the stream.
+	Vendor string
+	// Comments are the user comments in the form NAME=value.
+	Comments []string
+}
+
+func (t Tags) marshal() []byte {
+	b := append([]byte("OpusTags"), appendString(nil, t.Vendor)...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(t.Comments)))
+	for _, comment := range t.Comments {
+		b = appendString(b, comment)
+	}
+	return b
+}
+
+func appendString(b []byte, s string) []byte {
+	return append(binary.LittleEndian.AppendUint32(b, uint32(len(s))), s...)
+}
+
+func parseTags(packet []byte) (Tags, error) {
+	if len(packet) < 8 || string(packet[:8]) != "OpusTags" {
+		return Tags{}, fmt.Errorf("%w: missing OpusTags", ErrInvalidStream)
+	}
+	b := packet[8:]
+	next := func() (string, bool) {
+		if len(b) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(b)
+		if uint32(len(b)-4) < n {
+			return "", false
+		}
+		s := string(b[4 : 4+n])
+		b = b[4+n:]
+		return s, true
+	}
+
+	var (
+		t  Tags
+		ok bool
+	)
+	if t.Vendor, ok = next(); !ok || len(b) < 4 {
+		return Tags{}, fmt.Errorf("%w: truncated OpusTags", ErrInvalidStream)
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	for range count {
+		comment, ok := next()
+		if !ok {
+			return Tags{}, fmt.Errorf("%w: truncated OpusTags", ErrInvalidStream)
+		}
+		t.Comments = append(t.Comments, comment)
+	}
+	return t, nil
+}
+
+// frameSizes are the durations of the frames of each TOC configuration in samples, see RFC 6716 section 3.1.
+var frameSizes = [32]int{
+	// SILK NB, MB, WB
+	480, 960, 1920, 2880,
+	480, 960, 1920, 2880,
+	480, 960, 1920, 2880,
+	// Hybrid SWB, FB
+	480, 960,
+	480, 960,
+	// CELT NB, WB, SWB, FB
+	120, 240, 480, 960,
+	120, 240, 480, 960,
+	120, 240, 480, 960,
+	120, 240, 480, 960,
+}
+
+// PacketDuration returns the duration of the Opus packet in samples at 48 kHz, as encoded in its TOC byte.
+func PacketDuration(packet []byte) (int, error) {
+	if len(packet) == 0 {
+		return 0, fmt.Errorf("%w: empty packet", ErrInvalidPacket)
+	}
+	frameSize := frameSizes[packet[0]>>3]
+
+	var frames int
+	switch packet[0] & 0x03 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0, fmt.Errorf("%w: missing frame count", ErrInvalidPacket)
+		}
+		frames = int(packet[1] & 0x3F)
+	}
+
+	// packets are at most 120ms long
+	duration := frames * frameSize
+	if frames == 0 || duration > SampleRate*120/1000 {
+		return 0, fmt.Errorf("%w: %d frames of %d samples", ErrInvalidPacket, frames, frameSize)
+	}
+	return duration, nil
+}
@@ -0,0 +1,200 @@
+package oggopus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestCRC(t *testing.T) {
+	// CRC-32/POSIX without the final xor
+	if got, want := crc(0, []byte("123456789")), ^uint32(0x765E7680); got != want {
+		t.Errorf("expected crc %08x, got %08x", want, got)
+	}
+}
+
+func TestPacketDuration(t *testing.T) {
+	tests := []struct {
+		packet   []byte
+		duration int
+		err      error
+	}{
+		{packet: SilenceFrame, duration: 960},
+		{packet: []byte{0xFC, 0x00}, duration: 960},
+		{packet: []byte{0x00}, duration: 480},
+		{packet: []byte{0x10}, duration: 1920},
+		{packet: []byte{0x19}, duration: 5760},
+		{packet: []byte{0xE3, 0x06}, duration: 720},
+		{packet: []byte{0x1B, 0x03}, duration: 0, err: ErrInvalidPacket},
+		{packet: []byte{0x03}, err: ErrInvalidPacket},
+		{packet: nil, err: ErrInvalidPacket},
+	}
+	for _, tt := range tests {
+		duration, err := PacketDuration(tt.packet)
+		if !errors.Is(err, tt.err) || duration != tt.duration {
+			t.Errorf("PacketDuration(%x): expected %d, %v, got %d, %v", tt.packet, tt.duration, tt.err, duration, err)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, 7, Head{Channels: 2, PreSkip: 312, InputSampleRate: SampleRate}, Tags{Vendor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 60 packets of 20ms fill one page of a second and start a second one
+	for range 60 {
+		if err = w.WritePacket(SilenceFrame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WritePacket(SilenceFrame); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	type page struct {
+		headerType byte
+		granule    uint64
+		sequence   uint32
+		segments   int
+	}
+	var pages []page
+	for b := buf.Bytes(); len(b) > 0; {
+		if len(b) < pageHeaderSize || string(b[:4]) != "OggS" {
+			t.Fatalf("expected page at offset %d", buf.Len()-len(b))
+		}
+		segments := int(b[26])
+		size := pageHeaderSize + segments
+		for _, lacing := range b[pageHeaderSize : pageHeaderSize+segments] {
+			size += int(lacing)
+		}
+		checksum := binary.LittleEndian.Uint32(b[22:])
+		data := bytes.Clone(b[:size])
+		binary.LittleEndian.PutUint32(data[22:], 0)
+		if crc(0, data) != checksum {
+			t.Errorf("expected valid checksum of page %d", len(pages))
+		}
+		if binary.LittleEndian.Uint32(b[14:]) != 7 {
+			t.Errorf("expected serial 7 on page %d", len(pages))
+		}
+		pages = append(pages, page{
+			headerType: b[5],
+			granule:    binary.LittleEndian.Uint64(b[6:]),
+			sequence:   binary.LittleEndian.Uint32(b[18:]),
+			segments:   segments,
+		})
+		b = b[size:]
+	}
+
+	want := []page{
+		{headerType: headerBeginOfStream, granule: 0, sequence: 0, segments: 1},
+		{headerType: 0, granule: 0, sequence: 1, segments: 1},
+		{headerType: 0, granule: 312 + 50*960, sequence: 2, segments: 50},
+		{headerType: headerEndOfStream, granule: 312 + 60*960, sequence: 3, segments: 10},
+	}
+	if len(pages) != len(want) {
+		t.Fatalf("expected %d pages, got %+v", len(want), pages)
+	}
+	for i := range want {
+		if pages[i] != want[i] {
+			t.Errorf("expected page %d to be %+v, got %+v", i, want[i], pages[i])
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	large := bytes.Repeat([]byte{0xFC}, 600)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, 1, Head{Channels: 1, PreSkip: 312}, Tags{Vendor: "test", Comments: []string{"TITLE=test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a page of another multiplexed stream is skipped
+	buf.Write(appendPage(nil, headerBeginOfStream, 0, 2, 0, [][]byte{[]byte("other")}))
+	packets := [][]byte{SilenceFrame, large, {0xFC}}
+	for _, packet := range packets {
+		if err = w.WritePacket(packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head := r.Head(); head != (Head{Channels: 1, PreSkip: 312}) {
+		t.Errorf("expected head, got %+v", head)
+	}
+	if tags := r.Tags(); tags.Vendor != "test" || len(tags.Comments) != 1 || tags.Comments[0] != "TITLE=test" {
+		t.Errorf("expected tags, got %+v", tags)
+	}
+	for i, want := range packets {
+		packet, err := r.ReadPacket()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(packet, want) {
+			t.Errorf("expected packet %d to be %x, got %x", i, want, packet)
+		}
+	}
+	if _, err = r.ReadPacket(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if r.Granule() != 312+3*960 {
+		t.Errorf("expected granule position %d, got %d", 312+3*960, r.Granule())
+	}
+}
+
+func TestReaderContinuedPacket(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(appendPage(nil, headerBeginOfStream, 0, 1, 0, [][]byte{Head{Channels: 2}.marshal()}))
+	buf.Write(appendPage(nil, 0, 0, 1, 1, [][]byte{Tags{}.marshal()}))
+	// a packet of 300 bytes split after its first segment
+	packet := bytes.Repeat([]byte{0xFC}, 300)
+	page := appendPage(nil, 0, 0, 1, 2, [][]byte{packet})
+	first := append([]byte(nil), page[:pageHeaderSize]...)
+	first[26] = 1
+	first = append(first, 255)
+	first = append(first, packet[:255]...)
+	binary.LittleEndian.PutUint32(first[22:], 0)
+	binary.LittleEndian.PutUint32(first[22:], crc(0, first))
+	buf.Write(first)
+	second := appendPage(nil, headerContinued|headerEndOfStream, 960, 1, 3, [][]byte{packet[255:]})
+	buf.Write(second)
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.ReadPacket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, packet) {
+		t.Errorf("expected continued packet of %d bytes, got %d bytes", len(packet), len(got))
+	}
+}
+
+func TestReaderInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewWriter(&buf, 1, Head{Channels: 2}, Tags{}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xFF
+	if _, err := NewReader(bytes.NewReader(data)); !errors.Is(err, ErrInvalidStream) {
+		t.Errorf("expected ErrInvalidStream for corrupted page, got %v", err)
+	}
+	if _, err := NewReader(bytes.NewReader(nil)); !errors.Is(err, ErrInvalidStream) {
+		t.Errorf("expected ErrInvalidStream for empty stream, got %v", err)
+	}
+}
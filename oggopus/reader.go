@@ -0,0 +1,153 @@
+package oggopus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// NewReader reads the headers of the first Ogg Opus stream in r and returns a Reader for its audio packets.
+// Pages of other multiplexed streams are skipped.
+func NewReader(r io.Reader) (*Reader, error) {
+	or := &Reader{r: bufio.NewReader(r)}
+
+	packet, err := or.next(true)
+	if err != nil {
+		return nil, err
+	}
+	if or.head, err = parseHead(packet); err != nil {
+		return nil, err
+	}
+	if packet, err = or.ReadPacket(); err != nil {
+		return nil, noEOF(err)
+	}
+	if or.tags, err = parseTags(packet); err != nil {
+		return nil, err
+	}
+	return or, nil
+}
+
+// Reader reads the audio packets of an Ogg Opus stream.
+type Reader struct {
+	r      *bufio.Reader
+	serial uint32
+	head   Head
+	tags   Tags
+
+	header  [pageHeaderSize + maxSegments]byte
+	packets [][]byte
+	// partial is a packet continued on the next page.
+	partial []byte
+	eos     bool
+	granule uint64
+}
+
+// Head returns the identification header of the stream.
+func (r *Reader) Head() Head {
+	return r.head
+}
+
+// Tags returns the comment header of the stream.
+func (r *Reader) Tags() Tags {
+	return r.tags
+}
+
+// Granule returns the granule position of the last page read.
+func (r *Reader) Granule() uint64 {
+	return r.granule
+}
+
+// ReadPacket returns the next audio packet of the stream. It returns io.EOF after the last packet.
+func (r *Reader) ReadPacket() ([]byte, error) {
+	return r.next(false)
+}
+
+func (r *Reader) next(first bool) ([]byte, error) {
+	for len(r.packets) == 0 {
+		if r.eos {
+			return nil, io.EOF
+		}
+		if err := r.readPage(first); err != nil {
+			return nil, err
+		}
+	}
+	packet := r.packets[0]
+	r.packets = r.packets[1:]
+	return packet, nil
+}
+
+// readPage reads the next page of the stream. If first is set, it is the first page of a stream and sets its serial.
+func (r *Reader) readPage(first bool) error {
+	for {
+		header := r.header[:pageHeaderSize]
+		if _, err := io.ReadFull(r.r, header); err != nil {
+			if first && errors.Is(err, io.EOF) {
+				return fmt.Errorf("%w: no pages", ErrInvalidStream)
+			}
+			if errors.Is(err, io.EOF) {
+				// streams cut off without the end of stream flag are ended at the last complete page
+				return io.EOF
+			}
+			return noEOF(err)
+		}
+		if string(header[:4]) != "OggS" || header[4] != 0 {
+			return fmt.Errorf("%w: missing page capture pattern", ErrInvalidStream)
+		}
+		segments := int(header[26])
+		lacing := r.header[pageHeaderSize : pageHeaderSize+segments]
+		if _, err := io.ReadFull(r.r, lacing); err != nil {
+			return noEOF(err)
+		}
+		size := 0
+		for _, l := range lacing {
+			size += int(l)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			return noEOF(err)
+		}
+
+		checksum := binary.LittleEndian.Uint32(header[22:])
+		binary.LittleEndian.PutUint32(header[22:], 0)
+		if crc(crc(0, r.header[:pageHeaderSize+segments]), data) != checksum {
+			return fmt.Errorf("%w: invalid page checksum", ErrInvalidStream)
+		}
+
+		headerType := header[5]
+		serial := binary.LittleEndian.Uint32(header[14:])
+		if first {
+			if headerType&headerBeginOfStream == 0 {
+				return fmt.Errorf("%w: missing beginning of stream", ErrInvalidStream)
+			}
+			r.serial = serial
+			first = false
+		} else if serial != r.serial {
+			continue
+		}
+
+		if headerType&headerContinued == 0 {
+			// a packet continued on this page was lost
+			r.partial = r.partial[:0]
+		}
+		for _, l := range lacing {
+			r.partial = append(r.partial, data[:l]...)
+			data = data[l:]
+			if l < maxSegmentSize {
+				r.packets = append(r.packets, r.partial)
+				r.partial = nil
+			}
+		}
+		r.granule = binary.LittleEndian.Uint64(header[6:])
+		r.eos = headerType&headerEndOfStream != 0
+		return nil
+	}
+}
+
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package oggopus
+
+import (
+	"fmt"
+	"io"
+)
+
+// pageDuration is the duration of audio buffered in a page before it is written.
+const pageDuration = SampleRate
+
+// NewWriter writes the headers of an Ogg Opus stream with the given serial number to w and returns a Writer
+// for its audio packets.
+func NewWriter(w io.Writer, serial uint32, head Head, tags Tags) (*Writer, error) {
+	if head.Channels == 0 || head.Channels > 2 {
+		return nil, fmt.Errorf("%w: %d channels", ErrUnsupported, head.Channels)
+	}
+
+	ow := &Writer{
+		w:       w,
+		serial:  serial,
+		granule: uint64(head.PreSkip),
+	}
+	// the identification and comment headers are on pages of their own
+	if err := ow.writePage(headerBeginOfStream, 0, [][]byte{head.marshal()}); err != nil {
+		return nil, err
+	}
+	if err := ow.writePage(0, 0, [][]byte{tags.marshal()}); err != nil {
+		return nil, err
+	}
+	return ow, nil
+}
+
+// Writer writes the audio packets of an Ogg Opus stream. Packets are buffered and written in pages of about one
+// second, Flush writes the buffered packets. Close must be called to end the stream.
+type Writer struct {
+	w        io.Writer
+	serial   uint32
+	sequence uint32
+	granule  uint64
+	buf      []byte
+
+	packets  [][]byte
+	segments int
+	duration int
+	closed   bool
+}
+
+// Granule returns the granule position after the written packets, the number of samples including the pre-skip.
+func (w *Writer) Granule() uint64 {
+	return w.granule
+}
+
+// WritePacket writes an Opus packet and advances the granule position by its duration.
+func (w *Writer) WritePacket(packet []byte) error {
+	if w.closed {
+		return ErrClosed
+	}
+	duration, err := PacketDuration(packet)
+	if err != nil {
+		return err
+	}
+	if w.segments+segments(packet) > maxSegments || w.duration >= pageDuration {
+		if err = w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	w.packets = append(w.packets, append([]byte(nil), packet...))
+	w.segments += segments(packet)
+	w.duration += duration
+	w.granule += uint64(duration)
+	return nil
+}
+
+// Flush writes the buffered packets as a page.
+func (w *Writer) Flush() error {
+	if len(w.packets) == 0 {
+		return nil
+	}
+	return w.flush(0)
+}
+
+// Close writes the buffered packets as the last page of the stream. It does not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.flush(headerEndOfStream)
+}
+
+func (w *Writer) flush(headerType byte) error {
+	err := w.writePage(headerType, w.granule, w.packets)
+	w.packets = w.packets[:0]
+	w.segments = 0
+	w.duration = 0
+	return err
+}
+
+func (w *Writer) writePage(headerType byte, granule uint64, packets [][]byte) error {
+	w.buf = appendPage(w.buf[:0], headerType, granule, w.serial, w.sequence, packets)
+	w.sequence++
+	_, err := w.w.Write(w.buf)
+	return err
+}
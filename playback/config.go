@@ -0,0 +1,46 @@
+package playback
+
+import (
+	"time"
+)
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		TrailingSilence: 5,
+	}
+}
+
+// Config is the configuration used by players created with NewPlayer.
+type Config struct {
+	// ReadyTimeout is how long playback is held while the session is not ready before Play returns
+	// godave.ErrNotReady, 0 waits until the context is done.
+	ReadyTimeout time.Duration
+	// TrailingSilence is the number of silence frames sent after the stream, Discord expects 5 to stop
+	// interpolating audio.
+	TrailingSilence int
+}
+
+// ConfigOpt is a type alias for a function that takes a Config and is used to configure your player.
+type ConfigOpt func(config *Config)
+
+// Apply applies the given ConfigOpt(s) to the Config.
+func (c *Config) Apply(opts []ConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithReadyTimeout sets how long playback is held while the session is not ready.
+func WithReadyTimeout(timeout time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.ReadyTimeout = timeout
+	}
+}
+
+// WithTrailingSilence sets the number of silence frames sent after the stream.
+func WithTrailingSilence(frames int) ConfigOpt {
+	return func(config *Config) {
+		config.TrailingSilence = frames
+	}
+}
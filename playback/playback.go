@@ -0,0 +1,212 @@
+// Package playback sends the audio of Ogg Opus files over voice connections encrypted with DAVE.
+//
+// A Player reads the Opus packets of a stream, encrypts them with godave.Session.Encrypt and passes them to a send
+// function at the pace of their duration. While the session is not ready, playback is held instead of sending
+// frames unencrypted and continues where it stopped once the session is ready.
+package playback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/godave"
+	"github.com/disgoorg/godave/oggopus"
+)
+
+const (
+	// holdInterval is how often the readiness of the session is checked while playback is held.
+	holdInterval = 20 * time.Millisecond
+	// maxLag is how far playback may fall behind its schedule before it continues from now instead of catching up.
+	maxLag = 200 * time.Millisecond
+)
+
+// SendFunc sends an encrypted frame, for example as the payload of an RTP packet. duration is the duration of the
+// frame in samples at 48 kHz, the RTP timestamp of the next frame advances by it.
+type SendFunc func(frame []byte, duration int) error
+
+// NewPlayer returns a Player encrypting frames with session for the given SSRC. It assigns the Opus codec to the SSRC.
+func NewPlayer(session godave.Session, ssrc uint32, opts ...ConfigOpt) *Player {
+	config := DefaultConfig()
+	config.Apply(opts)
+
+	session.AssignSsrcToCodec(ssrc, godave.CodecOpus)
+	return &Player{
+		session: session,
+		ssrc:    ssrc,
+		config:  config,
+		clock:   &realClock{},
+	}
+}
+
+// Player plays Ogg Opus streams. Streams are played one at a time, concurrent calls of Play wait for each other.
+type Player struct {
+	session godave.Session
+	ssrc    uint32
+	config  *Config
+	clock   clock
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// PlayFile plays the Ogg Opus file at path, see Play.
+func (p *Player) PlayFile(ctx context.Context, path string, send SendFunc) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := oggopus.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return p.Play(ctx, r, send)
+}
+
+// Play sends the packets of the stream until its end or until ctx is done, followed by the trailing silence frames.
+// It returns godave.ErrNotReady if the session was not ready for longer than the ReadyTimeout.
+func (p *Player) Play(ctx context.Context, r *oggopus.Reader, send SendFunc) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pacer := newPacer(p.clock)
+
+	for {
+		packet, err := r.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err = p.play(ctx, pacer, packet, send); err != nil {
+			return err
+		}
+	}
+
+	for range p.config.TrailingSilence {
+		if err := p.play(ctx, pacer, oggopus.SilenceFrame, send); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// play waits until the packet is due, encrypts and sends it. It holds the packet while the session is not ready.
+func (p *Player) play(ctx context.Context, pacer *pacer, packet []byte, send SendFunc) error {
+	duration, err := oggopus.PacketDuration(packet)
+	if err != nil {
+		return err
+	}
+
+	var (
+		n             int
+		notReadySince time.Time
+	)
+	for {
+		if err = pacer.wait(ctx); err != nil {
+			return err
+		}
+
+		n, err = p.encrypt(packet)
+		if errors.Is(err, godave.ErrNotReady) {
+			now := p.clock.Now()
+			if notReadySince.IsZero() {
+				notReadySince = now
+			} else if p.config.ReadyTimeout > 0 && now.Sub(notReadySince) >= p.config.ReadyTimeout {
+				return err
+			}
+			pacer.hold(now)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		pacer.advance(duration)
+		return send(p.buf[:n], duration)
+	}
+}
+
+func (p *Player) encrypt(packet []byte) (int, error) {
+	if !p.session.Ready() {
+		return 0, godave.ErrNotReady
+	}
+	size := p.session.MaxEncryptedFrameSize(len(packet))
+	if cap(p.buf) < size {
+		p.buf = make([]byte, size)
+	}
+	return p.session.Encrypt(p.ssrc, packet, p.buf[:size])
+}
+
+// clock is the time source of the pacer, tests replace it to check the schedule without waiting.
+type clock interface {
+	Now() time.Time
+	// SleepUntil waits until the deadline or until ctx is done.
+	SleepUntil(ctx context.Context, deadline time.Time) error
+}
+
+// realClock sleeps with a timer reused for every frame.
+type realClock struct {
+	timer *time.Timer
+}
+
+func (c *realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *realClock) SleepUntil(ctx context.Context, deadline time.Time) error {
+	// the timer of a past deadline fires immediately, which must not win against a done context
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c.timer == nil {
+		c.timer = time.NewTimer(time.Until(deadline))
+	} else {
+		c.timer.Reset(time.Until(deadline))
+	}
+	select {
+	case <-ctx.Done():
+		c.timer.Stop()
+		return ctx.Err()
+	case <-c.timer.C:
+		return nil
+	}
+}
+
+// pacer schedules frames at the pace of their duration.
+type pacer struct {
+	clock clock
+	next  time.Time
+}
+
+func newPacer(clock clock) *pacer {
+	return &pacer{
+		clock: clock,
+		next:  clock.Now(),
+	}
+}
+
+// wait waits until the next frame is due.
+func (p *pacer) wait(ctx context.Context) error {
+	if lag := p.clock.Now().Sub(p.next); lag > maxLag {
+		p.next = p.next.Add(lag)
+	}
+	return p.clock.SleepUntil(ctx, p.next)
+}
+
+// advance schedules the next frame after a frame of the given duration in samples.
+func (p *pacer) advance(duration int) {
+	p.next = p.next.Add(time.Duration(duration) * time.Second / oggopus.SampleRate)
+}
+
+// hold postpones the next frame, the schedule continues from now once frames are sent again.
+func (p *pacer) hold(now time.Time) {
+	p.next = now.Add(holdInterval)
+}
@@ -0,0 +1,188 @@
+package playback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/godave"
+	"github.com/disgoorg/godave/oggopus"
+)
+
+// fakeClock advances to the deadline instead of sleeping.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) SleepUntil(ctx context.Context, deadline time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if deadline.After(c.now) {
+		c.now = deadline
+	}
+	return nil
+}
+
+// fakeSession prefixes encrypted frames with their SSRC.
+type fakeSession struct {
+	godave.Session
+	ready atomic.Bool
+	// readyAt makes the session ready at the time of the clock if set.
+	clock   *fakeClock
+	readyAt time.Time
+	ssrc    uint32
+}
+
+func (s *fakeSession) Ready() bool {
+	if s.clock != nil {
+		return !s.clock.Now().Before(s.readyAt)
+	}
+	return s.ready.Load()
+}
+
+func (s *fakeSession) AssignSsrcToCodec(ssrc uint32, codec godave.Codec) {
+	if codec == godave.CodecOpus {
+		s.ssrc = ssrc
+	}
+}
+
+func (s *fakeSession) MaxEncryptedFrameSize(frameSize int) int {
+	return frameSize + 1
+}
+
+func (s *fakeSession) Encrypt(ssrc uint32, frame []byte, encryptedFrame []byte) (int, error) {
+	if ssrc != s.ssrc {
+		return 0, errors.New("unassigned ssrc")
+	}
+	return copy(encryptedFrame, append([]byte{byte(ssrc)}, frame...)), nil
+}
+
+func newFakeSession() *fakeSession {
+	return &fakeSession{Session: godave.NewNoopSession(slog.New(slog.DiscardHandler), "bot", nil)}
+}
+
+// writeStream writes an Ogg Opus stream of n packets of 2.5ms.
+func writeStream(t *testing.T, n int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := oggopus.NewWriter(&buf, 1, oggopus.Head{Channels: 2}, oggopus.Tags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range n {
+		if err = w.WritePacket([]byte{0xE0, byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type sent struct {
+	frame    []byte
+	duration int
+	at       time.Time
+}
+
+func TestPlay(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	start := clock.Now()
+	session := newFakeSession()
+	session.clock, session.readyAt = clock, start.Add(50*time.Millisecond)
+	player := NewPlayer(session, 7, WithTrailingSilence(2))
+	player.clock = clock
+
+	r, err := oggopus.NewReader(bytes.NewReader(writeStream(t, 20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frames []sent
+	err = player.Play(context.Background(), r, func(frame []byte, duration int) error {
+		frames = append(frames, sent{frame: bytes.Clone(frame), duration: duration, at: clock.Now()})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frames) != 22 {
+		t.Fatalf("expected 22 frames, got %d", len(frames))
+	}
+	// readiness is checked every 20ms while playback is held
+	if first := frames[0].at.Sub(start); first != 60*time.Millisecond {
+		t.Errorf("expected playback to be held until 60ms, started at %s", first)
+	}
+	for i, f := range frames[:20] {
+		if want := []byte{7, 0xE0, byte(i)}; !bytes.Equal(f.frame, want) || f.duration != 120 {
+			t.Errorf("expected frame %d to be %x of 120 samples, got %x of %d samples", i, want, f.frame, f.duration)
+		}
+	}
+	for _, f := range frames[20:] {
+		if want := append([]byte{7}, oggopus.SilenceFrame...); !bytes.Equal(f.frame, want) || f.duration != 960 {
+			t.Errorf("expected silence frame, got %x of %d samples", f.frame, f.duration)
+		}
+	}
+	// frames of 2.5ms followed by the silence frames of 20ms
+	for i, f := range frames {
+		want := time.Duration(min(i, 20))*2500*time.Microsecond + time.Duration(max(i-20, 0))*20*time.Millisecond
+		if got := f.at.Sub(frames[0].at); got != want {
+			t.Errorf("expected frame %d to be sent at %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestPlayReadyTimeout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	session := newFakeSession()
+	session.clock, session.readyAt = clock, clock.Now().Add(time.Hour)
+	player := NewPlayer(session, 7, WithReadyTimeout(50*time.Millisecond))
+	player.clock = clock
+
+	r, err := oggopus.NewReader(bytes.NewReader(writeStream(t, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = player.Play(context.Background(), r, func([]byte, int) error {
+		t.Error("expected no frame to be sent")
+		return nil
+	})
+	if !errors.Is(err, godave.ErrNotReady) {
+		t.Errorf("expected ErrNotReady, got %v", err)
+	}
+}
+
+func TestPlayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sound.opus")
+	if err := os.WriteFile(path, writeStream(t, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	session := newFakeSession()
+	session.ready.Store(true)
+	player := NewPlayer(session, 7)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := 0
+	err := player.PlayFile(ctx, path, func([]byte, int) error {
+		if frames++; frames == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || frames != 10 {
+		t.Errorf("expected playback to stop after 10 frames, got %d frames and %v", frames, err)
+	}
+}
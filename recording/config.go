@@ -0,0 +1,99 @@
+package recording
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/godave"
+)
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		Logger:   slog.Default(),
+		Dir:      ".",
+		Channels: 2,
+		MaxGap:   5 * time.Minute,
+		Clock:    time.Now,
+	}
+}
+
+// Config is the configuration used by sinks created with NewSink.
+type Config struct {
+	// Logger logs the users whose recording started.
+	Logger *slog.Logger
+	// Dir is the directory the files are created in, named after the user ID with the .opus extension.
+	// Existing files are not overwritten.
+	Dir string
+	// Create creates the file of a user. Nil creates the files in Dir.
+	Create func(userID godave.UserID) (io.WriteCloser, error)
+	// Filter reports whether a user is recorded, for example whether they consented. Nil records all users.
+	// Frames of users which are not recorded are not decrypted.
+	Filter func(userID godave.UserID) bool
+	// Channels is the number of channels written to the Opus header, Discord sends stereo audio.
+	Channels uint8
+	// MaxGap is the longest silence inserted between two frames of a user.
+	MaxGap time.Duration
+	// Clock returns the arrival time of frames, used to measure gaps when the timestamps of a user are not continuous.
+	Clock func() time.Time
+}
+
+// ConfigOpt is a type alias for a function that takes a Config and is used to configure your sink.
+type ConfigOpt func(config *Config)
+
+// Apply applies the given ConfigOpt(s) to the Config.
+func (c *Config) Apply(opts []ConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithLogger sets the logger used to log the users whose recording started.
+func WithLogger(logger *slog.Logger) ConfigOpt {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithDir sets the directory the files are created in.
+func WithDir(dir string) ConfigOpt {
+	return func(config *Config) {
+		config.Dir = dir
+	}
+}
+
+// WithCreate sets the function creating the file of a user.
+func WithCreate(create func(userID godave.UserID) (io.WriteCloser, error)) ConfigOpt {
+	return func(config *Config) {
+		config.Create = create
+	}
+}
+
+// WithFilter sets the function reporting whether a user is recorded.
+func WithFilter(filter func(userID godave.UserID) bool) ConfigOpt {
+	return func(config *Config) {
+		config.Filter = filter
+	}
+}
+
+// WithChannels sets the number of channels written to the Opus header.
+func WithChannels(channels uint8) ConfigOpt {
+	return func(config *Config) {
+		config.Channels = channels
+	}
+}
+
+// WithMaxGap sets the longest silence inserted between two frames of a user.
+func WithMaxGap(maxGap time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.MaxGap = maxGap
+	}
+}
+
+// WithClock sets the function returning the arrival time of frames.
+func WithClock(clock func() time.Time) ConfigOpt {
+	return func(config *Config) {
+		config.Clock = clock
+	}
+}
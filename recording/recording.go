@@ -0,0 +1,195 @@
+// Package recording writes the audio received on a voice connection to one Ogg Opus file per user.
+//
+// A Sink decrypts the frames of every user with godave.Session.Decrypt and writes the Opus packets to the file
+// of the user. Granule positions follow the RTP timestamps of the frames: when a user stops speaking, silence
+// is inserted until their next frame, so the position of every packet in the file matches the time it was
+// spoken relative to the first frame of the user.
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/godave"
+	"github.com/disgoorg/godave/oggopus"
+)
+
+// ErrClosed is returned by Sink.WriteFrame after Sink.Close.
+var ErrClosed = errors.New("recording: sink closed")
+
+// lateWindow is how far the timestamp of a frame may lie before the end of the previous frame of the user
+// for it to be dropped as late or duplicate, earlier timestamps are a discontinuity.
+const lateWindow = oggopus.SampleRate
+
+// NewSink returns a Sink decrypting frames with session.
+func NewSink(session godave.Session, opts ...ConfigOpt) *Sink {
+	config := DefaultConfig()
+	config.Apply(opts)
+	if config.Create == nil {
+		dir := config.Dir
+		config.Create = func(userID godave.UserID) (io.WriteCloser, error) {
+			return os.OpenFile(filepath.Join(dir, string(userID)+".opus"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		}
+	}
+
+	return &Sink{
+		session: session,
+		config:  config,
+		tracks:  make(map[godave.UserID]*track),
+	}
+}
+
+// Sink writes the audio of every user to its own Ogg Opus file. It is safe for concurrent use.
+type Sink struct {
+	session godave.Session
+	config  *Config
+
+	mu     sync.Mutex
+	tracks map[godave.UserID]*track
+	buf    []byte
+	closed bool
+}
+
+type track struct {
+	file   io.WriteCloser
+	writer *oggopus.Writer
+	// next is the RTP timestamp following the last frame.
+	next        uint32
+	lastArrival time.Time
+	// residual is the part of the inserted silence shorter than a silence frame, carried over to the next gap.
+	residual int
+}
+
+// WriteFrame decrypts a frame of the user and writes it to the file of the user, which is created with the first
+// frame. timestamp is the RTP timestamp of the voice packet of the frame. Frames arriving late are dropped.
+func (s *Sink) WriteFrame(userID godave.UserID, timestamp uint32, frame []byte) error {
+	if s.config.Filter != nil && !s.config.Filter(userID) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+
+	size := s.session.MaxDecryptedFrameSize(userID, len(frame))
+	if cap(s.buf) < size {
+		s.buf = make([]byte, size)
+	}
+	n, err := s.session.Decrypt(userID, frame, s.buf[:size])
+	if err != nil {
+		return fmt.Errorf("failed to decrypt frame of user %s: %w", userID, err)
+	}
+	packet := s.buf[:n]
+	if len(packet) == 0 {
+		return nil
+	}
+	duration, err := oggopus.PacketDuration(packet)
+	if err != nil {
+		return err
+	}
+
+	now := s.config.Clock()
+	t, ok := s.tracks[userID]
+	if !ok {
+		if t, err = s.newTrack(userID); err != nil {
+			return err
+		}
+		s.tracks[userID] = t
+	} else if err = t.fill(timestamp, now, s.config.MaxGap); errors.Is(err, errLate) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err = t.writer.WritePacket(packet); err != nil {
+		return err
+	}
+	t.next = timestamp + uint32(duration)
+	t.lastArrival = now
+	return nil
+}
+
+var errLate = errors.New("late frame")
+
+// fill writes silence from the end of the previous frame until timestamp. If the timestamps are not continuous,
+// for example because the user reconnected with a new SSRC, the gap is measured with the arrival times.
+func (t *track) fill(timestamp uint32, now time.Time, maxGap time.Duration) error {
+	maxSamples := int64(maxGap * oggopus.SampleRate / time.Second)
+	gap := int64(int32(timestamp - t.next))
+	if gap < 0 && gap > -lateWindow {
+		return errLate
+	}
+	if gap < 0 || gap > maxSamples {
+		// the previous frame ended about when this one arrived if there was no gap
+		gap = int64(now.Sub(t.lastArrival)*oggopus.SampleRate/time.Second) - oggopus.SilenceFrameDuration
+		gap = min(max(gap, 0), maxSamples)
+	}
+
+	gap += int64(t.residual)
+	t.residual = int(gap % oggopus.SilenceFrameDuration)
+	for range gap / oggopus.SilenceFrameDuration {
+		if err := t.writer.WritePacket(oggopus.SilenceFrame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sink) newTrack(userID godave.UserID) (*track, error) {
+	file, err := s.config.Create(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file of user %s: %w", userID, err)
+	}
+
+	writer, err := oggopus.NewWriter(file, rand.Uint32(), oggopus.Head{
+		Channels:        s.config.Channels,
+		InputSampleRate: oggopus.SampleRate,
+	}, oggopus.Tags{
+		Vendor:   "godave",
+		Comments: []string{"USER_ID=" + string(userID)},
+	})
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	s.config.Logger.Debug("started recording user", slog.String("user_id", string(userID)))
+	return &track{file: file, writer: writer}, nil
+}
+
+// Users returns the users with a file, sorted by their ID.
+func (s *Sink) Users() []godave.UserID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]godave.UserID, 0, len(s.tracks))
+	for userID := range s.tracks {
+		users = append(users, userID)
+	}
+	slices.Sort(users)
+	return users
+}
+
+// Close ends the streams of all users and closes their files. It does not close the session.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var errs []error
+	for _, t := range s.tracks {
+		errs = append(errs, t.writer.Close(), t.file.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,165 @@
+package recording
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/godave"
+	"github.com/disgoorg/godave/oggopus"
+)
+
+type buffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *buffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+// readPackets returns the audio packets of an Ogg Opus stream and the granule position of its last page.
+func readPackets(t *testing.T, b []byte) ([][]byte, uint64) {
+	t.Helper()
+	r, err := oggopus.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var packets [][]byte
+	for {
+		packet, err := r.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return packets, r.Granule()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		packets = append(packets, packet)
+	}
+}
+
+func TestSink(t *testing.T) {
+	files := make(map[godave.UserID]*buffer)
+	sink := NewSink(godave.NewNoopSession(slog.New(slog.DiscardHandler), "bot", nil),
+		WithCreate(func(userID godave.UserID) (io.WriteCloser, error) {
+			files[userID] = &buffer{}
+			return files[userID], nil
+		}),
+		WithFilter(func(userID godave.UserID) bool {
+			return userID != "2"
+		}),
+	)
+
+	frame := []byte{0xFC, 0x01, 0x02}
+	for _, timestamp := range []uint32{1000, 1960, 1000, 2920 + 4*960} {
+		if err := sink.WriteFrame("1", timestamp, frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.WriteFrame("2", 1000, frame); err != nil {
+		t.Fatal(err)
+	}
+	if users := sink.Users(); !slices.Equal(users, []godave.UserID{"1"}) {
+		t.Errorf("expected only user 1 to be recorded, got %v", users)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.WriteFrame("1", 10000, frame); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	if !files["1"].closed {
+		t.Error("expected file to be closed")
+	}
+	packets, granule := readPackets(t, files["1"].Bytes())
+	// the late frame is dropped and the gap of 4 frames is filled with silence
+	want := [][]byte{frame, frame, oggopus.SilenceFrame, oggopus.SilenceFrame, oggopus.SilenceFrame, oggopus.SilenceFrame, frame}
+	if len(packets) != len(want) {
+		t.Fatalf("expected %d packets, got %x", len(want), packets)
+	}
+	for i := range want {
+		if !bytes.Equal(packets[i], want[i]) {
+			t.Errorf("expected packet %d to be %x, got %x", i, want[i], packets[i])
+		}
+	}
+	if granule != 7*960 {
+		t.Errorf("expected granule position %d, got %d", 7*960, granule)
+	}
+}
+
+func TestSinkDir(t *testing.T) {
+	dir := t.TempDir()
+	session := godave.NewNoopSession(slog.New(slog.DiscardHandler), "bot", nil)
+
+	sink := NewSink(session, WithDir(dir))
+	if err := sink.WriteFrame("1", 0, oggopus.SilenceFrame); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1.opus")); err != nil {
+		t.Fatal(err)
+	}
+
+	// existing recordings are not overwritten
+	sink = NewSink(session, WithDir(dir))
+	if err := sink.WriteFrame("1", 0, oggopus.SilenceFrame); !errors.Is(err, os.ErrExist) {
+		t.Errorf("expected os.ErrExist, got %v", err)
+	}
+}
+
+func TestSinkReconnect(t *testing.T) {
+	var file buffer
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sink := NewSink(godave.NewNoopSession(slog.New(slog.DiscardHandler), "bot", nil),
+		WithCreate(func(godave.UserID) (io.WriteCloser, error) {
+			return &file, nil
+		}),
+		WithMaxGap(200*time.Millisecond),
+		WithClock(func() time.Time {
+			return now
+		}),
+	)
+
+	frame := []byte{0xFC, 0x01, 0x02}
+	for _, write := range []struct {
+		timestamp uint32
+		after     time.Duration
+	}{
+		{timestamp: 100000},
+		// the user reconnected with a new SSRC and timestamp 120ms after the previous frame arrived
+		{timestamp: 1000, after: 120 * time.Millisecond},
+		// the timestamps jump far beyond MaxGap
+		{timestamp: 1000000, after: time.Hour},
+	} {
+		now = now.Add(write.after)
+		if err := sink.WriteFrame("1", write.timestamp, frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	packets, _ := readPackets(t, file.Bytes())
+	// 100ms of silence after the 20ms frame, then MaxGap
+	want := slices.Concat([][]byte{frame}, slices.Repeat([][]byte{oggopus.SilenceFrame}, 5),
+		[][]byte{frame}, slices.Repeat([][]byte{oggopus.SilenceFrame}, 10), [][]byte{frame})
+	if len(packets) != len(want) {
+		t.Fatalf("expected %d packets, got %x", len(want), packets)
+	}
+	for i := range want {
+		if !bytes.Equal(packets[i], want[i]) {
+			t.Errorf("expected packet %d to be %x, got %x", i, want[i], packets[i])
+		}
+	}
+}
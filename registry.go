@@ -0,0 +1,154 @@
+package godave
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+const (
+	// ImplementationEnv is the environment variable selecting the Session implementation by name.
+	ImplementationEnv = "GODAVE_IMPL"
+	// AllowFallbackEnv is the environment variable allowing fallback implementations to be selected
+	// when no other implementation is available.
+	AllowFallbackEnv = "GODAVE_ALLOW_FALLBACK"
+)
+
+var (
+	ErrUnknownImplementation     = errors.New("unknown DAVE implementation")
+	ErrImplementationUnavailable = errors.New("DAVE implementation unavailable")
+	ErrNoImplementation          = errors.New("no DAVE implementation available")
+)
+
+var (
+	implementationsMu sync.RWMutex
+	implementations   = map[string]Implementation{}
+)
+
+func init() {
+	Register(Implementation{
+		Name:     "noop",
+		Create:   NewNoopSession,
+		Fallback: true,
+	})
+}
+
+// Implementation describes a Session implementation registered with Register.
+type Implementation struct {
+	// Name is the unique name of the implementation.
+	Name string
+	// Priority orders the implementations, the available implementation with the highest priority is selected by default.
+	Priority int
+	// Create creates sessions of this implementation.
+	Create SessionCreateFunc
+	// Available reports why the implementation can not be used. Nil means it is always available.
+	Available func() error
+	// Fallback marks implementations which do not provide E2EE. They are only selected by name or when explicitly allowed.
+	Fallback bool
+}
+
+func (i Implementation) available() error {
+	if i.Available == nil {
+		return nil
+	}
+	return i.Available()
+}
+
+// Register makes a Session implementation available by its name.
+// Implementations usually register themselves in an init function, so they are registered by importing their package.
+// It panics if Register is called twice with the same name or if Create is nil.
+func Register(implementation Implementation) {
+	implementationsMu.Lock()
+	defer implementationsMu.Unlock()
+
+	if implementation.Create == nil {
+		panic("godave: Register create func is nil")
+	}
+	if _, ok := implementations[implementation.Name]; ok {
+		panic("godave: Register called twice for implementation " + implementation.Name)
+	}
+	implementations[implementation.Name] = implementation
+}
+
+// unregister removes the implementation with the given name, it is used by tests to undo Register.
+func unregister(name string) {
+	implementationsMu.Lock()
+	defer implementationsMu.Unlock()
+
+	delete(implementations, name)
+}
+
+// Implementations returns all registered implementations ordered by descending priority.
+func Implementations() []Implementation {
+	implementationsMu.RLock()
+	defer implementationsMu.RUnlock()
+
+	all := make([]Implementation, 0, len(implementations))
+	for _, implementation := range implementations {
+		all = append(all, implementation)
+	}
+	slices.SortFunc(all, func(a, b Implementation) int {
+		if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return all
+}
+
+// SelectImplementation returns the registered implementation with the given name,
+// or the available implementation with the highest priority if name is empty.
+// Fallback implementations are only selected without a name if allowFallback is true.
+func SelectImplementation(name string, allowFallback bool) (Implementation, error) {
+	if name != "" {
+		implementationsMu.RLock()
+		implementation, ok := implementations[name]
+		implementationsMu.RUnlock()
+		if !ok {
+			return Implementation{}, fmt.Errorf("%w: %s", ErrUnknownImplementation, name)
+		}
+		if err := implementation.available(); err != nil {
+			return Implementation{}, fmt.Errorf("%w: %s: %w", ErrImplementationUnavailable, name, err)
+		}
+		return implementation, nil
+	}
+
+	var errs []error
+	for _, implementation := range Implementations() {
+		if implementation.Fallback && !allowFallback {
+			continue
+		}
+		if err := implementation.available(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", implementation.Name, err))
+			continue
+		}
+		return implementation, nil
+	}
+
+	return Implementation{}, errors.Join(append([]error{ErrNoImplementation}, errs...)...)
+}
+
+// DefaultSessionCreateFunc returns the SessionCreateFunc of the implementation selected by SelectImplementation.
+// The implementation is selected by the GODAVE_IMPL environment variable if set, fallback implementations are
+// allowed if GODAVE_ALLOW_FALLBACK is set to true. The selected implementation is logged when the first session is created.
+func DefaultSessionCreateFunc() (SessionCreateFunc, error) {
+	allowFallback, _ := strconv.ParseBool(os.Getenv(AllowFallbackEnv))
+
+	implementation, err := SelectImplementation(os.Getenv(ImplementationEnv), allowFallback)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func(logger *slog.Logger, userID UserID, callbacks Callbacks) Session {
+		once.Do(func() {
+			logger.Info("using DAVE implementation", slog.String("name", implementation.Name))
+		})
+		return implementation.Create(logger, userID, callbacks)
+	}, nil
+}
@@ -0,0 +1,50 @@
+package godave
+
+import (
+	"errors"
+	"testing"
+)
+
+// register registers the implementation for the duration of the test.
+func register(t *testing.T, implementation Implementation) {
+	t.Helper()
+	Register(implementation)
+	t.Cleanup(func() {
+		unregister(implementation.Name)
+	})
+}
+
+func TestSelectImplementation(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	register(t, Implementation{
+		Name:      "test-unavailable",
+		Priority:  200,
+		Create:    NewNoopSession,
+		Available: func() error { return errUnavailable },
+	})
+	register(t, Implementation{
+		Name:     "test-available",
+		Priority: 100,
+		Create:   NewNoopSession,
+	})
+
+	implementation, err := SelectImplementation("", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if implementation.Name != "test-available" {
+		t.Errorf("expected test-available, got %s", implementation.Name)
+	}
+
+	if _, err = SelectImplementation("test-unavailable", false); !errors.Is(err, errUnavailable) {
+		t.Errorf("expected unavailable error, got %v", err)
+	}
+
+	if _, err = SelectImplementation("unknown", false); !errors.Is(err, ErrUnknownImplementation) {
+		t.Errorf("expected unknown implementation error, got %v", err)
+	}
+
+	if implementation, err = SelectImplementation("noop", false); err != nil || implementation.Name != "noop" {
+		t.Errorf("expected noop, got %s: %v", implementation.Name, err)
+	}
+}
@@ -0,0 +1,96 @@
+package godave
+
+import (
+	"bytes"
+	"maps"
+	"time"
+)
+
+// Roster maps the users of a MLS group to their signature keys.
+type Roster map[UserID][]byte
+
+// Clone returns a deep copy of the Roster.
+func (r Roster) Clone() Roster {
+	clone := make(Roster, len(r))
+	for userID, key := range r {
+		clone[userID] = bytes.Clone(key)
+	}
+	return clone
+}
+
+// Diff returns the RosterUpdate transforming the Roster into the given new Roster.
+func (r Roster) Diff(newRoster Roster) RosterUpdate {
+	update := RosterUpdate{
+		Added:   Roster{},
+		Removed: Roster{},
+		Changed: map[UserID]RosterKeyChange{},
+	}
+
+	for userID, key := range newRoster {
+		oldKey, ok := r[userID]
+		if !ok {
+			update.Added[userID] = key
+		} else if !bytes.Equal(oldKey, key) {
+			update.Changed[userID] = RosterKeyChange{Old: oldKey, New: key}
+		}
+	}
+
+	for userID, key := range r {
+		if _, ok := newRoster[userID]; !ok {
+			update.Removed[userID] = key
+		}
+	}
+
+	return update
+}
+
+// RosterProvider is implemented by Sessions that track the roster of their MLS group.
+type RosterProvider interface {
+	// Roster returns a snapshot of the current MLS group roster.
+	Roster() Roster
+}
+
+// RosterKeyChange is the old and new signature key of a user whose key changed.
+type RosterKeyChange struct {
+	Old []byte
+	New []byte
+}
+
+// RosterUpdate describes the changes a commit or welcome applied to the MLS group roster.
+type RosterUpdate struct {
+	// TransitionID is the transition ID of the commit or welcome.
+	TransitionID uint16
+	// Added are the users added to the MLS group.
+	Added Roster
+	// Removed are the users removed from the MLS group with their last known signature key.
+	Removed Roster
+	// Changed are the users whose signature key changed.
+	Changed map[UserID]RosterKeyChange
+	// UpdatedAt is when the update was applied.
+	UpdatedAt time.Time
+}
+
+// Empty reports whether the RosterUpdate contains no changes.
+func (u RosterUpdate) Empty() bool {
+	return len(u.Added) == 0 && len(u.Removed) == 0 && len(u.Changed) == 0
+}
+
+// Apply returns a copy of the Roster with the RosterUpdate applied.
+func (u RosterUpdate) Apply(roster Roster) Roster {
+	roster = maps.Clone(roster)
+	if roster == nil {
+		roster = Roster{}
+	}
+	for userID := range u.Removed {
+		delete(roster, userID)
+	}
+	maps.Copy(roster, u.Added)
+	for userID, change := range u.Changed {
+		roster[userID] = change.New
+	}
+	return roster
+}
+
+// RosterUpdateHandler is called by a Session for every non-empty RosterUpdate.
+// It is called synchronously from the goroutine delivering the voice gateway events and should not block.
+type RosterUpdateHandler func(update RosterUpdate)
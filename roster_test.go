@@ -0,0 +1,90 @@
+package godave
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRosterDiff(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		old, new Roster
+		want     RosterUpdate
+	}{
+		{
+			name: "empty",
+			want: RosterUpdate{Added: Roster{}, Removed: Roster{}, Changed: map[UserID]RosterKeyChange{}},
+		},
+		{
+			name: "added",
+			old:  Roster{"1": []byte("a")},
+			new:  Roster{"1": []byte("a"), "2": []byte("b")},
+			want: RosterUpdate{Added: Roster{"2": []byte("b")}, Removed: Roster{}, Changed: map[UserID]RosterKeyChange{}},
+		},
+		{
+			name: "removed",
+			old:  Roster{"1": []byte("a"), "2": []byte("b")},
+			new:  Roster{"1": []byte("a")},
+			want: RosterUpdate{Added: Roster{}, Removed: Roster{"2": []byte("b")}, Changed: map[UserID]RosterKeyChange{}},
+		},
+		{
+			name: "changed",
+			old:  Roster{"1": []byte("a")},
+			new:  Roster{"1": []byte("b")},
+			want: RosterUpdate{Added: Roster{}, Removed: Roster{}, Changed: map[UserID]RosterKeyChange{
+				"1": {Old: []byte("a"), New: []byte("b")},
+			}},
+		},
+		{
+			name: "reset",
+			old:  Roster{"1": []byte("a"), "2": []byte("b")},
+			new:  nil,
+			want: RosterUpdate{Added: Roster{}, Removed: Roster{"1": []byte("a"), "2": []byte("b")}, Changed: map[UserID]RosterKeyChange{}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			update := tt.old.Diff(tt.new)
+			if !reflect.DeepEqual(update, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, update)
+			}
+			if update.Empty() != (tt.name == "empty") {
+				t.Errorf("expected Empty to be %t", tt.name == "empty")
+			}
+
+			// applying the diff to the old roster results in the new roster
+			want := tt.new
+			if want == nil {
+				want = Roster{}
+			}
+			if got := update.Apply(tt.old); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected Apply to return %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRosterUpdateApplyCopies(t *testing.T) {
+	roster := Roster{"1": []byte("a")}
+	update := RosterUpdate{Removed: Roster{"1": []byte("a")}}
+
+	if got := update.Apply(roster); len(got) != 0 {
+		t.Errorf("expected an empty roster, got %v", got)
+	}
+	if len(roster) != 1 {
+		t.Errorf("expected Apply not to modify the roster, got %v", roster)
+	}
+}
+
+func TestRosterClone(t *testing.T) {
+	roster := Roster{"1": []byte("a")}
+	clone := roster.Clone()
+	if !reflect.DeepEqual(clone, roster) {
+		t.Fatalf("expected %v, got %v", roster, clone)
+	}
+
+	clone["1"][0] = 'b'
+	clone["2"] = []byte("c")
+	if string(roster["1"]) != "a" || len(roster) != 1 {
+		t.Errorf("expected the clone not to share memory with the roster, got %v", roster)
+	}
+}
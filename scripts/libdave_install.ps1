@@ -126,10 +126,10 @@ includedir=$Prefix/include
 
 Name: dave
 Description: Discord Audio & Video End-to-End Encryption (DAVE) Protocol
-Version: $Version
+Version: $($Version.Replace('/cpp',''))
 URL: $LibDaveRepo
 Libs: -L`${libdir} -ldave
-Cflags: -I`${includedir}
+Cflags: -I`${includedir} -DLIBDAVE_VERSION=$($Version.Replace('/cpp',''))
 "@
 
     Out-File -FilePath $PcFile -InputObject $PcContent -Encoding UTF8
@@ -133,10 +133,10 @@ includedir=\${prefix}/include
 
 Name: dave
 Description: Discord Audio & Video End-to-End Encryption (DAVE) Protocol
-Version: $VERSION
+Version: ${VERSION%/cpp}
 URL: $LIBDAVE_REPO
 Libs: -L\${libdir} -ldave -Wl,-rpath,\${libdir}
-Cflags: -I\${includedir}
+Cflags: -I\${includedir} -DLIBDAVE_VERSION=${VERSION%/cpp}
 EOF
 }
 
@@ -0,0 +1,124 @@
+package godave
+
+import (
+	"time"
+)
+
+// SecurityEvent is emitted by a Session when the security properties of the call change.
+type SecurityEvent interface {
+	// Time returns when the event was detected.
+	Time() time.Time
+}
+
+// SecurityEventHandler is called by a Session for every SecurityEvent it emits.
+// It is called synchronously from the goroutine delivering the voice gateway events and should not block.
+type SecurityEventHandler func(event SecurityEvent)
+
+// DowngradeReason describes why a Session lost its established E2EE epoch.
+type DowngradeReason int
+
+const (
+	// DowngradeReasonTransition means the voice gateway transitioned the call to protocol version 0.
+	DowngradeReasonTransition DowngradeReason = iota + 1
+	// DowngradeReasonCommitFailure means a commit could not be processed and the MLS group was reset.
+	DowngradeReasonCommitFailure
+	// DowngradeReasonWelcomeFailure means a welcome could not be processed and the MLS group was reset.
+	DowngradeReasonWelcomeFailure
+	// DowngradeReasonAdmissionDenied means a commit added a user rejected by the AdmissionPolicy and the MLS group was reset.
+	DowngradeReasonAdmissionDenied
+	// DowngradeReasonProtocolVersionRejected means the voice gateway moved the call to a protocol version above the
+	// maximum supported by the Session.
+	DowngradeReasonProtocolVersionRejected
+)
+
+func (r DowngradeReason) String() string {
+	switch r {
+	case DowngradeReasonTransition:
+		return "transition"
+	case DowngradeReasonCommitFailure:
+		return "commit_failure"
+	case DowngradeReasonWelcomeFailure:
+		return "welcome_failure"
+	case DowngradeReasonAdmissionDenied:
+		return "admission_denied"
+	case DowngradeReasonProtocolVersionRejected:
+		return "protocol_version_rejected"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	_ SecurityEvent = (*DowngradeEvent)(nil)
+	_ SecurityEvent = (*AdmissionDeniedEvent)(nil)
+	_ SecurityEvent = (*IdentityKeyChangedEvent)(nil)
+	_ SecurityEvent = (*ProtocolVersionRejectedEvent)(nil)
+)
+
+// DowngradeEvent is emitted when a Session that had an established E2EE epoch stops encrypting
+// or loses its MLS group. Applications should treat it as the channel losing end-to-end encryption.
+type DowngradeEvent struct {
+	// Reason is the classification of the downgrade.
+	Reason DowngradeReason
+	// TransitionID is the transition ID that caused the downgrade.
+	TransitionID uint16
+	// ProtocolVersion is the DAVE protocol version of the epoch that was lost.
+	ProtocolVersion uint16
+	// EstablishedAt is when the lost E2EE epoch was established.
+	EstablishedAt time.Time
+	// DetectedAt is when the downgrade was detected.
+	DetectedAt time.Time
+}
+
+func (e *DowngradeEvent) Time() time.Time {
+	return e.DetectedAt
+}
+
+// AdmissionDeniedEvent is emitted when the AdmissionPolicy of a Session rejects a user,
+// either when the user joins the voice channel or when a commit adds them to the MLS group.
+type AdmissionDeniedEvent struct {
+	// UserID is the rejected user.
+	UserID UserID
+	// TransitionID is the transition ID of the commit adding the user, or 0 if the user was rejected when joining.
+	TransitionID uint16
+	// DetectedAt is when the user was rejected.
+	DetectedAt time.Time
+}
+
+func (e *AdmissionDeniedEvent) Time() time.Time {
+	return e.DetectedAt
+}
+
+// IdentityKeyChangedEvent is emitted when the signature key of a user differs from the key pinned for them.
+type IdentityKeyChangedEvent struct {
+	// UserID is the user whose key changed.
+	UserID UserID
+	// PinnedKey is the signature key previously pinned for the user.
+	PinnedKey []byte
+	// Key is the signature key the user presented.
+	Key []byte
+	// DetectedAt is when the change was detected.
+	DetectedAt time.Time
+}
+
+func (e *IdentityKeyChangedEvent) Time() time.Time {
+	return e.DetectedAt
+}
+
+// ProtocolVersionRejectedEvent is emitted when the voice gateway selects, prepares or commits a protocol version above
+// the maximum supported by a Session. The Session falls back to the maximum supported protocol version, or reports the
+// transition as invalid to the voice gateway.
+type ProtocolVersionRejectedEvent struct {
+	// TransitionID is the transition ID moving to the rejected protocol version, or 0 for a new MLS group.
+	TransitionID uint16
+	// ProtocolVersion is the rejected protocol version.
+	ProtocolVersion uint16
+	// MaxProtocolVersion is the maximum protocol version supported by the Session.
+	MaxProtocolVersion uint16
+	// DetectedAt is when the protocol version was rejected.
+	DetectedAt time.Time
+}
+
+func (e *ProtocolVersionRejectedEvent) Time() time.Time {
+	return e.DetectedAt
+}
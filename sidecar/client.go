@@ -0,0 +1,342 @@
+package sidecar
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/godave"
+)
+
+// NewClient connects to the sidecar server configured with the given ConfigOpt(s).
+// It returns an error if the first connection attempt fails, later disconnects are handled by reconnecting.
+func NewClient(opts ...ConfigOpt) (*Client, error) {
+	config := DefaultConfig()
+	config.Apply(opts)
+	if config.Dialer == nil {
+		config.Dialer = (&net.Dialer{}).DialContext
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+		sessions: make(map[*session]struct{}),
+		remote:   make(map[uint32]*session),
+		pending:  make(map[uint32]chan response),
+	}
+
+	cn, err := c.dial()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	c.conn = cn
+	go c.readLoop(cn)
+	return c, nil
+}
+
+// Client creates sessions hosted by a sidecar server. All sessions of a Client share its connection.
+//
+// If the connection is lost, calls fail with ErrDisconnected and Ready returns false until the Client has reconnected.
+// The sidecar closes the sessions of disconnected clients, so the Client recreates every session after reconnecting,
+// restores its channel, SSRCs, users and external sender package and, if DAVE was negotiated, calls
+// OnSelectProtocolAck again, which makes the session send a new key package to rejoin the MLS group.
+type Client struct {
+	config *Config
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu                 sync.Mutex
+	conn               *conn
+	sessions           map[*session]struct{}
+	remote             map[uint32]*session
+	pending            map[uint32]chan response
+	nextID             uint32
+	maxProtocolVersion int
+	closed             bool
+}
+
+type response struct {
+	m   message
+	err error
+}
+
+var responsePool = sync.Pool{
+	New: func() any {
+		return make(chan response, 1)
+	},
+}
+
+// Connected reports whether the Client is connected to the sidecar server.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Close closes the connection and stops reconnecting. The sessions of the Client fail with ErrClosed afterward.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	cn := c.conn
+	c.mu.Unlock()
+
+	c.cancel()
+	if cn != nil {
+		c.disconnect(cn, ErrClosed)
+	}
+	return nil
+}
+
+// CreateSession is a godave.SessionCreateFunc creating a session hosted by the sidecar server.
+// If the Client is disconnected, the session is created once it has reconnected.
+func (c *Client) CreateSession(logger *slog.Logger, userID godave.UserID, callbacks godave.Callbacks) godave.Session {
+	s := &session{
+		client:    c,
+		logger:    logger,
+		userID:    userID,
+		callbacks: callbacks,
+		ssrcs:     make(map[uint32]godave.Codec),
+		users:     make(map[godave.UserID]struct{}),
+	}
+
+	c.mu.Lock()
+	c.sessions[s] = struct{}{}
+	cn := c.conn
+	c.mu.Unlock()
+
+	if cn != nil {
+		s.restore(cn)
+	}
+	return s
+}
+
+func (c *Client) dial() (*conn, error) {
+	nc, err := c.config.Dialer(c.ctx, c.config.Network, c.config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDisconnected, err)
+	}
+	return newConn(nc), nil
+}
+
+// call sends a request of the session and waits for its response. It returns a decoder of the result and whether
+// the session is ready.
+func (c *Client) call(cn *conn, sessionID uint32, op op, payload []byte) (*decoder, bool, error) {
+	m, err := c.roundTrip(cn, message{kind: kindRequest, op: op, session: sessionID, payload: payload})
+	if err != nil {
+		return nil, false, err
+	}
+
+	d := &decoder{b: m.payload}
+	ready := d.uint8()&1 == 1
+	if err = d.result(); err != nil {
+		return nil, ready, err
+	}
+	return d, ready, nil
+}
+
+func (c *Client) roundTrip(cn *conn, m message) (message, error) {
+	ch := responsePool.Get().(chan response)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return message{}, ErrClosed
+	}
+	if cn == nil || c.conn != cn {
+		c.mu.Unlock()
+		return message{}, ErrDisconnected
+	}
+	c.nextID++
+	m.id = c.nextID
+	c.pending[m.id] = ch
+	c.mu.Unlock()
+
+	if err := cn.write(m); err != nil {
+		// fails the pending request
+		c.disconnect(cn, err)
+	}
+
+	r := <-ch
+	responsePool.Put(ch)
+	return r.m, r.err
+}
+
+func (c *Client) readLoop(cn *conn) {
+	for {
+		m, err := cn.read()
+		if err != nil {
+			c.disconnect(cn, err)
+			return
+		}
+
+		switch m.kind {
+		case kindResponse:
+			c.mu.Lock()
+			ch, ok := c.pending[m.id]
+			delete(c.pending, m.id)
+			c.mu.Unlock()
+			if ok {
+				ch <- response{m: m}
+			}
+		case kindCallback:
+			c.mu.Lock()
+			s := c.remote[m.session]
+			c.mu.Unlock()
+			// callbacks may call back into the session, which needs the read loop to receive the responses
+			go c.callback(cn, s, m)
+		default:
+			c.disconnect(cn, fmt.Errorf("%w: unexpected kind %d", errMalformedMessage, m.kind))
+			return
+		}
+	}
+}
+
+func (c *Client) callback(cn *conn, s *session, m message) {
+	var err error
+	if s == nil {
+		err = fmt.Errorf("%w: %d", errUnknownSession, m.session)
+	} else {
+		d := &decoder{b: m.payload}
+		switch m.op {
+		case opSendMLSKeyPackage:
+			err = s.callbacks.SendMLSKeyPackage(d.b)
+		case opSendMLSCommitWelcome:
+			err = s.callbacks.SendMLSCommitWelcome(d.b)
+		case opSendReadyForTransition:
+			if transitionID := d.uint16(); d.err == nil {
+				err = s.callbacks.SendReadyForTransition(transitionID)
+			}
+		case opSendInvalidCommitWelcome:
+			if transitionID := d.uint16(); d.err == nil {
+				err = s.callbacks.SendInvalidCommitWelcome(transitionID)
+			}
+		default:
+			err = fmt.Errorf("%w: %s", errUnknownOperation, m.op)
+		}
+		if d.err != nil {
+			err = d.err
+		}
+	}
+
+	if werr := cn.write(message{kind: kindCallbackResponse, op: m.op, id: m.id, session: m.session, payload: appendResult(nil, nil, err)}); werr != nil {
+		c.disconnect(cn, werr)
+	}
+}
+
+// disconnect fails all pending calls of the connection and starts reconnecting unless the Client is closed.
+func (c *Client) disconnect(cn *conn, cause error) {
+	c.mu.Lock()
+	if c.conn != cn {
+		c.mu.Unlock()
+		return
+	}
+	c.conn = nil
+	pending := c.pending
+	c.pending = make(map[uint32]chan response)
+	c.remote = make(map[uint32]*session)
+	sessions := make([]*session, 0, len(c.sessions))
+	for s := range c.sessions {
+		sessions = append(sessions, s)
+	}
+	closed := c.closed
+	c.mu.Unlock()
+
+	_ = cn.Close()
+	err := ErrDisconnected
+	if closed {
+		err = ErrClosed
+	}
+	for _, ch := range pending {
+		ch <- response{err: err}
+	}
+	for _, s := range sessions {
+		s.disconnected()
+	}
+
+	if closed {
+		return
+	}
+	c.config.Logger.Warn("lost connection to DAVE sidecar, reconnecting", slog.Int("sessions", len(sessions)), slog.Any("err", cause))
+	go c.reconnect()
+}
+
+func (c *Client) reconnect() {
+	delay := c.config.ReconnectDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		cn, err := c.dial()
+		if err != nil {
+			c.config.Logger.Debug("failed to reconnect to DAVE sidecar", slog.Int("attempt", attempt), slog.Any("err", err))
+			delay = min(delay*2, c.config.MaxReconnectDelay)
+			continue
+		}
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			_ = cn.Close()
+			return
+		}
+		c.conn = cn
+		sessions := make([]*session, 0, len(c.sessions))
+		for s := range c.sessions {
+			sessions = append(sessions, s)
+		}
+		c.mu.Unlock()
+
+		go c.readLoop(cn)
+		c.config.Logger.Info("reconnected to DAVE sidecar", slog.Int("attempt", attempt), slog.Int("sessions", len(sessions)))
+		for _, s := range sessions {
+			s.restore(cn)
+		}
+		return
+	}
+}
+
+// create creates the session on the sidecar server. It returns the remote ID of the session, its maximum supported
+// protocol version and whether it is ready.
+func (c *Client) create(cn *conn, s *session) (uint32, int, bool, error) {
+	m, err := c.roundTrip(cn, message{kind: kindRequest, op: opCreate, payload: appendString(nil, string(s.userID))})
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	d := &decoder{b: m.payload}
+	ready := d.uint8()&1 == 1
+	if err = d.result(); err != nil {
+		return 0, 0, false, err
+	}
+	maxProtocolVersion := int(d.uint32())
+	if d.err != nil {
+		return 0, 0, false, d.err
+	}
+
+	c.mu.Lock()
+	if c.conn == cn {
+		c.remote[m.session] = s
+	}
+	c.maxProtocolVersion = maxProtocolVersion
+	c.mu.Unlock()
+	return m.session, maxProtocolVersion, ready, nil
+}
+
+func (c *Client) remove(s *session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sessions, s)
+	for id, rs := range c.remote {
+		if rs == s {
+			delete(c.remote, id)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package sidecar
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		Logger:            slog.Default(),
+		Network:           "unix",
+		Address:           DefaultSocketPath,
+		ReconnectDelay:    100 * time.Millisecond,
+		MaxReconnectDelay: 10 * time.Second,
+	}
+}
+
+// DefaultSocketPath is the socket path used by the sidecar server and clients by default.
+var DefaultSocketPath = filepath.Join(os.TempDir(), "godave-sidecar.sock")
+
+// Config is the configuration used by clients created with NewClient.
+type Config struct {
+	// Logger logs connection events of the client.
+	Logger *slog.Logger
+	// Network and Address are the network and address of the sidecar server.
+	Network string
+	Address string
+	// Dialer connects to the sidecar server. Nil uses a net.Dialer.
+	Dialer func(ctx context.Context, network string, address string) (net.Conn, error)
+	// ReconnectDelay is the delay before the first reconnect attempt, doubled up to MaxReconnectDelay for every failed attempt.
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+}
+
+// ConfigOpt is a type alias for a function that takes a Config and is used to configure your client.
+type ConfigOpt func(config *Config)
+
+// Apply applies the given ConfigOpt(s) to the Config.
+func (c *Config) Apply(opts []ConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithLogger sets the logger used to log connection events of the client.
+func WithLogger(logger *slog.Logger) ConfigOpt {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithAddress sets the network and address of the sidecar server.
+func WithAddress(network string, address string) ConfigOpt {
+	return func(config *Config) {
+		config.Network = network
+		config.Address = address
+	}
+}
+
+// WithSocketPath sets the path of the Unix socket of the sidecar server.
+func WithSocketPath(path string) ConfigOpt {
+	return WithAddress("unix", path)
+}
+
+// WithDialer sets the function used to connect to the sidecar server.
+func WithDialer(dialer func(ctx context.Context, network string, address string) (net.Conn, error)) ConfigOpt {
+	return func(config *Config) {
+		config.Dialer = dialer
+	}
+}
+
+// WithReconnectDelay sets the delay before the first reconnect attempt and the maximum delay between attempts.
+func WithReconnectDelay(delay time.Duration, maxDelay time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.ReconnectDelay = delay
+		config.MaxReconnectDelay = maxDelay
+	}
+}
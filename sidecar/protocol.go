@@ -0,0 +1,283 @@
+package sidecar
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/disgoorg/godave"
+)
+
+// The sidecar protocol exchanges length-prefixed binary messages over a stream connection:
+//
+//	length (uint32) | kind (uint8) | op (uint8) | id (uint32) | session (uint32) | payload
+//
+// Clients send requests, which the server answers with a response of the same id. While handling a request the
+// server sends callbacks of the session to the client, which answers them with a callback response of the same id.
+// All integers are big-endian, byte slices and strings are prefixed with their length as uint32.
+
+const (
+	kindRequest uint8 = iota + 1
+	kindResponse
+	kindCallback
+	kindCallbackResponse
+)
+
+type op uint8
+
+const (
+	opCreate op = iota + 1
+	opClose
+	opSetChannelID
+	opAssignSsrcToCodec
+	opMaxEncryptedFrameSize
+	opEncrypt
+	opMaxDecryptedFrameSize
+	opDecrypt
+	opAddUser
+	opRemoveUser
+	opOnSelectProtocolAck
+	opOnDavePrepareTransition
+	opOnDaveExecuteTransition
+	opOnDavePrepareEpoch
+	opOnDaveMLSExternalSenderPackage
+	opOnDaveMLSProposals
+	opOnDaveMLSPrepareCommitTransition
+	opOnDaveMLSWelcome
+
+	opSendMLSKeyPackage
+	opSendMLSCommitWelcome
+	opSendReadyForTransition
+	opSendInvalidCommitWelcome
+)
+
+var opNames = map[op]string{
+	opCreate:                           "Create",
+	opClose:                            "Close",
+	opSetChannelID:                     "SetChannelID",
+	opAssignSsrcToCodec:                "AssignSsrcToCodec",
+	opMaxEncryptedFrameSize:            "MaxEncryptedFrameSize",
+	opEncrypt:                          "Encrypt",
+	opMaxDecryptedFrameSize:            "MaxDecryptedFrameSize",
+	opDecrypt:                          "Decrypt",
+	opAddUser:                          "AddUser",
+	opRemoveUser:                       "RemoveUser",
+	opOnSelectProtocolAck:              "OnSelectProtocolAck",
+	opOnDavePrepareTransition:          "OnDavePrepareTransition",
+	opOnDaveExecuteTransition:          "OnDaveExecuteTransition",
+	opOnDavePrepareEpoch:               "OnDavePrepareEpoch",
+	opOnDaveMLSExternalSenderPackage:   "OnDaveMLSExternalSenderPackage",
+	opOnDaveMLSProposals:               "OnDaveMLSProposals",
+	opOnDaveMLSPrepareCommitTransition: "OnDaveMLSPrepareCommitTransition",
+	opOnDaveMLSWelcome:                 "OnDaveMLSWelcome",
+	opSendMLSKeyPackage:                "SendMLSKeyPackage",
+	opSendMLSCommitWelcome:             "SendMLSCommitWelcome",
+	opSendReadyForTransition:           "SendReadyForTransition",
+	opSendInvalidCommitWelcome:         "SendInvalidCommitWelcome",
+}
+
+func (o op) String() string {
+	if name, ok := opNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(o))
+}
+
+// status of a response or callback response, followed by the result or the error message.
+const (
+	statusOK uint8 = iota
+	statusError
+	statusNotReady
+)
+
+const (
+	headerSize = 4 + 1 + 1 + 4 + 4
+	// maxMessageSize limits the memory allocated for a message announced by the peer.
+	maxMessageSize = 16 << 20
+)
+
+var (
+	ErrDisconnected     = errors.New("sidecar: not connected")
+	ErrClosed           = errors.New("sidecar: closed")
+	errMalformedMessage = errors.New("sidecar: malformed message")
+	errMessageTooLarge  = errors.New("sidecar: message too large")
+	errUnknownSession   = errors.New("sidecar: unknown session")
+	errUnknownOperation = errors.New("sidecar: unknown operation")
+)
+
+// RemoteError is an error returned by the session or callbacks on the other end of the connection.
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+type message struct {
+	kind    uint8
+	op      op
+	id      uint32
+	session uint32
+	payload []byte
+}
+
+// conn reads and writes messages. Writes are safe for concurrent use, reads are done by a single goroutine.
+type conn struct {
+	c  net.Conn
+	r  *bufio.Reader
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+func newConn(c net.Conn) *conn {
+	return &conn{
+		c: c,
+		r: bufio.NewReader(c),
+		w: bufio.NewWriter(c),
+	}
+}
+
+func (c *conn) write(m message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var header [headerSize]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(headerSize-4+len(m.payload)))
+	header[4] = m.kind
+	header[5] = byte(m.op)
+	binary.BigEndian.PutUint32(header[6:], m.id)
+	binary.BigEndian.PutUint32(header[10:], m.session)
+	if _, err := c.w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := c.w.Write(m.payload); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func (c *conn) read() (message, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return message{}, err
+	}
+	length := int(binary.BigEndian.Uint32(header[0:]))
+	if length < headerSize-4 {
+		return message{}, errMalformedMessage
+	}
+	if length > maxMessageSize {
+		return message{}, fmt.Errorf("%w: %d bytes", errMessageTooLarge, length)
+	}
+
+	m := message{
+		kind:    header[4],
+		op:      op(header[5]),
+		id:      binary.BigEndian.Uint32(header[6:]),
+		session: binary.BigEndian.Uint32(header[10:]),
+		payload: make([]byte, length-(headerSize-4)),
+	}
+	if _, err := io.ReadFull(c.r, m.payload); err != nil {
+		return message{}, err
+	}
+	return m, nil
+}
+
+func (c *conn) Close() error {
+	return c.c.Close()
+}
+
+func appendBytes(b []byte, data []byte) []byte {
+	return append(binary.BigEndian.AppendUint32(b, uint32(len(data))), data...)
+}
+
+func appendString(b []byte, s string) []byte {
+	return append(binary.BigEndian.AppendUint32(b, uint32(len(s))), s...)
+}
+
+// appendResult appends the status of err followed by its message, or statusOK followed by result.
+func appendResult(b []byte, result []byte, err error) []byte {
+	switch {
+	case err == nil:
+		return append(append(b, statusOK), result...)
+	case errors.Is(err, godave.ErrNotReady):
+		return append(b, statusNotReady)
+	default:
+		return appendString(append(b, statusError), err.Error())
+	}
+}
+
+// decoder reads the payload of a message. The first error is kept in err.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = errMalformedMessage
+		return nil
+	}
+	b := d.b[:n:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) uint8() uint8 {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) bytes() []byte {
+	return d.next(int(d.uint32()))
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+// result reads the status written by appendResult and returns the error it encodes.
+func (d *decoder) result() error {
+	switch status := d.uint8(); {
+	case d.err != nil:
+		return d.err
+	case status == statusOK:
+		return nil
+	case status == statusNotReady:
+		return godave.ErrNotReady
+	case status == statusError:
+		return &RemoteError{Message: d.string()}
+	default:
+		return fmt.Errorf("%w: unknown status %d", errMalformedMessage, status)
+	}
+}
@@ -0,0 +1,370 @@
+package sidecar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+
+	"github.com/disgoorg/godave"
+)
+
+var (
+	// ErrServerClosed is returned by Server.Serve after Server.Close.
+	ErrServerClosed = errors.New("sidecar: server closed")
+	errSessionBusy  = errors.New("sidecar: too many pending requests for session")
+)
+
+// sessionQueueSize is the number of requests buffered per session.
+const sessionQueueSize = 64
+
+// NewServer returns a Server hosting sessions created by create.
+func NewServer(create godave.SessionCreateFunc, logger *slog.Logger) *Server {
+	return &Server{
+		create:    create,
+		logger:    logger,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[*serverConn]struct{}),
+	}
+}
+
+// Server hosts sessions for clients connected over a Unix socket. The sessions of a client are closed when it disconnects.
+type Server struct {
+	create godave.SessionCreateFunc
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*serverConn]struct{}
+	closed    bool
+}
+
+// Serve accepts clients on the listener until it fails or the Server is closed, in which case ErrServerClosed is returned.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			delete(s.listeners, l)
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		sc := &serverConn{
+			server:    s,
+			conn:      newConn(c),
+			sessions:  make(map[uint32]*serverSession),
+			callbacks: make(map[uint32]chan message),
+			done:      make(chan struct{}),
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = c.Close()
+			return ErrServerClosed
+		}
+		s.conns[sc] = struct{}{}
+		s.mu.Unlock()
+
+		go sc.serve()
+	}
+}
+
+// Close stops all listeners and disconnects all clients, closing their sessions.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var errs []error
+	for l := range s.listeners {
+		errs = append(errs, l.Close())
+	}
+	conns := make([]*serverConn, 0, len(s.conns))
+	for sc := range s.conns {
+		conns = append(conns, sc)
+	}
+	s.mu.Unlock()
+
+	for _, sc := range conns {
+		sc.close()
+	}
+	return errors.Join(errs...)
+}
+
+type serverConn struct {
+	server *Server
+	conn   *conn
+
+	mu             sync.Mutex
+	sessions       map[uint32]*serverSession
+	nextSessionID  uint32
+	callbacks      map[uint32]chan message
+	nextCallbackID uint32
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func (sc *serverConn) serve() {
+	defer func() {
+		sc.close()
+		// queues are only closed by this goroutine, which is the only one sending to them
+		sc.mu.Lock()
+		sessions := sc.sessions
+		sc.sessions = nil
+		sc.mu.Unlock()
+		for _, ss := range sessions {
+			close(ss.queue)
+		}
+	}()
+
+	for {
+		m, err := sc.conn.read()
+		if err != nil {
+			select {
+			case <-sc.done:
+			default:
+				sc.server.logger.Debug("sidecar client disconnected", slog.Any("err", err))
+			}
+			return
+		}
+
+		switch m.kind {
+		case kindRequest:
+			if m.op == opCreate {
+				sc.createSession(m)
+				continue
+			}
+			sc.mu.Lock()
+			ss, ok := sc.sessions[m.session]
+			if ok && m.op == opClose {
+				delete(sc.sessions, m.session)
+			}
+			sc.mu.Unlock()
+			if !ok {
+				sc.respond(m, false, nil, fmt.Errorf("%w: %d", errUnknownSession, m.session))
+				continue
+			}
+			select {
+			case ss.queue <- m:
+			default:
+				// blocking would stall the callback responses the session may be waiting for
+				sc.respond(m, false, nil, fmt.Errorf("%w: %d", errSessionBusy, m.session))
+				continue
+			}
+			if m.op == opClose {
+				close(ss.queue)
+			}
+		case kindCallbackResponse:
+			sc.mu.Lock()
+			ch, ok := sc.callbacks[m.id]
+			delete(sc.callbacks, m.id)
+			sc.mu.Unlock()
+			if ok {
+				ch <- m
+			}
+		default:
+			sc.server.logger.Error("sidecar client sent unexpected message", slog.Int("kind", int(m.kind)))
+			return
+		}
+	}
+}
+
+func (sc *serverConn) createSession(m message) {
+	d := &decoder{b: m.payload}
+	userID := godave.UserID(d.string())
+	if d.err != nil {
+		sc.respond(m, false, nil, d.err)
+		return
+	}
+
+	sc.mu.Lock()
+	sc.nextSessionID++
+	id := sc.nextSessionID
+	sc.mu.Unlock()
+
+	ss := &serverSession{
+		conn:  sc,
+		id:    id,
+		queue: make(chan message, sessionQueueSize),
+	}
+	ss.session = sc.server.create(sc.server.logger.With(slog.String("user_id", string(userID))), userID, ss)
+
+	sc.mu.Lock()
+	sc.sessions[id] = ss
+	sc.mu.Unlock()
+	go ss.serve()
+
+	m.session = id
+	sc.respond(m, ss.session.Ready(), binary.BigEndian.AppendUint32(nil, uint32(ss.session.MaxSupportedProtocolVersion())), nil)
+}
+
+func (sc *serverConn) respond(request message, ready bool, result []byte, err error) {
+	var flags byte
+	if ready {
+		flags = 1
+	}
+	if werr := sc.conn.write(message{
+		kind:    kindResponse,
+		op:      request.op,
+		id:      request.id,
+		session: request.session,
+		payload: appendResult([]byte{flags}, result, err),
+	}); werr != nil {
+		sc.server.logger.Debug("failed to write sidecar response", slog.Any("err", werr))
+	}
+}
+
+// callback sends a callback of the session to the client and waits for its response.
+func (sc *serverConn) callback(session uint32, op op, payload []byte) error {
+	ch := make(chan message, 1)
+	sc.mu.Lock()
+	sc.nextCallbackID++
+	id := sc.nextCallbackID
+	sc.callbacks[id] = ch
+	sc.mu.Unlock()
+
+	if err := sc.conn.write(message{kind: kindCallback, op: op, id: id, session: session, payload: payload}); err != nil {
+		sc.mu.Lock()
+		delete(sc.callbacks, id)
+		sc.mu.Unlock()
+		return err
+	}
+
+	select {
+	case m := <-ch:
+		d := &decoder{b: m.payload}
+		return d.result()
+	case <-sc.done:
+		return ErrDisconnected
+	}
+}
+
+func (sc *serverConn) close() {
+	sc.closeOnce.Do(func() {
+		close(sc.done)
+		_ = sc.conn.Close()
+
+		sc.server.mu.Lock()
+		delete(sc.server.conns, sc)
+		sc.server.mu.Unlock()
+	})
+}
+
+// serverSession handles the requests of a session in order and forwards its callbacks to the client.
+type serverSession struct {
+	conn    *serverConn
+	id      uint32
+	session godave.Session
+	queue   chan message
+	buf     []byte
+}
+
+func (ss *serverSession) serve() {
+	defer func() {
+		if err := ss.session.Close(); err != nil {
+			ss.conn.server.logger.Error("failed to close sidecar session", slog.Any("err", err))
+		}
+	}()
+
+	for m := range ss.queue {
+		result, err := ss.handle(m)
+		ss.conn.respond(m, ss.session.Ready(), result, err)
+		if m.op == opClose {
+			return
+		}
+	}
+}
+
+func (ss *serverSession) handle(m message) ([]byte, error) {
+	s := ss.session
+	d := &decoder{b: m.payload}
+	switch m.op {
+	case opClose:
+		// closed by serve after the response
+	case opSetChannelID:
+		s.SetChannelID(godave.ChannelID(d.uint64()))
+	case opAssignSsrcToCodec:
+		s.AssignSsrcToCodec(d.uint32(), godave.Codec(d.uint32()))
+	case opMaxEncryptedFrameSize:
+		return binary.BigEndian.AppendUint32(nil, uint32(s.MaxEncryptedFrameSize(int(d.uint32())))), d.err
+	case opEncrypt:
+		ssrc, frame := d.uint32(), d.bytes()
+		if d.err != nil {
+			return nil, d.err
+		}
+		ss.buf = grow(ss.buf, s.MaxEncryptedFrameSize(len(frame)))
+		n, err := s.Encrypt(ssrc, frame, ss.buf)
+		return ss.buf[:n], err
+	case opMaxDecryptedFrameSize:
+		userID, frameSize := godave.UserID(d.string()), d.uint32()
+		return binary.BigEndian.AppendUint32(nil, uint32(s.MaxDecryptedFrameSize(userID, int(frameSize)))), d.err
+	case opDecrypt:
+		userID, frame := godave.UserID(d.string()), d.bytes()
+		if d.err != nil {
+			return nil, d.err
+		}
+		ss.buf = grow(ss.buf, s.MaxDecryptedFrameSize(userID, len(frame)))
+		n, err := s.Decrypt(userID, frame, ss.buf)
+		return ss.buf[:n], err
+	case opAddUser:
+		s.AddUser(godave.UserID(d.string()))
+	case opRemoveUser:
+		s.RemoveUser(godave.UserID(d.string()))
+	case opOnSelectProtocolAck:
+		s.OnSelectProtocolAck(d.uint16())
+	case opOnDavePrepareTransition:
+		s.OnDavePrepareTransition(d.uint16(), d.uint16())
+	case opOnDaveExecuteTransition:
+		s.OnDaveExecuteTransition(d.uint16())
+	case opOnDavePrepareEpoch:
+		s.OnDavePrepareEpoch(int(d.uint64()), d.uint16())
+	case opOnDaveMLSExternalSenderPackage:
+		s.OnDaveMLSExternalSenderPackage(d.bytes())
+	case opOnDaveMLSProposals:
+		s.OnDaveMLSProposals(d.bytes())
+	case opOnDaveMLSPrepareCommitTransition:
+		s.OnDaveMLSPrepareCommitTransition(d.uint16(), d.bytes())
+	case opOnDaveMLSWelcome:
+		s.OnDaveMLSWelcome(d.uint16(), d.bytes())
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownOperation, m.op)
+	}
+	return nil, d.err
+}
+
+func grow(b []byte, n int) []byte {
+	if cap(b) < n {
+		return make([]byte, n)
+	}
+	return b[:n]
+}
+
+func (ss *serverSession) SendMLSKeyPackage(mlsKeyPackage []byte) error {
+	return ss.conn.callback(ss.id, opSendMLSKeyPackage, mlsKeyPackage)
+}
+
+func (ss *serverSession) SendMLSCommitWelcome(mlsCommitWelcome []byte) error {
+	return ss.conn.callback(ss.id, opSendMLSCommitWelcome, mlsCommitWelcome)
+}
+
+func (ss *serverSession) SendReadyForTransition(transitionID uint16) error {
+	return ss.conn.callback(ss.id, opSendReadyForTransition, binary.BigEndian.AppendUint16(nil, transitionID))
+}
+
+func (ss *serverSession) SendInvalidCommitWelcome(transitionID uint16) error {
+	return ss.conn.callback(ss.id, opSendInvalidCommitWelcome, binary.BigEndian.AppendUint16(nil, transitionID))
+}
@@ -0,0 +1,294 @@
+package sidecar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/disgoorg/godave"
+)
+
+var payloadPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+var _ godave.Session = (*session)(nil)
+
+// session forwards its calls to the session hosted by the sidecar server. It keeps the state needed to recreate
+// the remote session after reconnecting.
+type session struct {
+	client    *Client
+	logger    *slog.Logger
+	userID    godave.UserID
+	callbacks godave.Callbacks
+	ready     atomic.Bool
+
+	// mu is held by calls changing the state of the session, so they are ordered with restore.
+	mu                 sync.Mutex
+	conn               *conn
+	remoteID           uint32
+	maxProtocolVersion int
+	closed             bool
+
+	channelID       godave.ChannelID
+	ssrcs           map[uint32]godave.Codec
+	users           map[godave.UserID]struct{}
+	protocolVersion uint16
+	// externalSender is the last external sender package, the voice gateway does not send it again.
+	externalSender []byte
+}
+
+// restore creates the session on the connection and replays its state.
+func (s *session) restore(cn *conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	remoteID, maxProtocolVersion, ready, err := s.client.create(cn, s)
+	if err != nil {
+		s.logger.Error("failed to create sidecar session", slog.Any("err", err))
+		return
+	}
+	s.conn, s.remoteID, s.maxProtocolVersion = cn, remoteID, maxProtocolVersion
+	s.ready.Store(ready)
+
+	if s.channelID != 0 {
+		s.sendLocked(opSetChannelID, binary.BigEndian.AppendUint64(nil, uint64(s.channelID)))
+	}
+	for ssrc, codec := range s.ssrcs {
+		s.sendLocked(opAssignSsrcToCodec, binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, ssrc), uint32(codec)))
+	}
+	for userID := range s.users {
+		s.sendLocked(opAddUser, appendString(nil, string(userID)))
+	}
+	if s.externalSender != nil {
+		s.sendLocked(opOnDaveMLSExternalSenderPackage, appendBytes(nil, s.externalSender))
+	}
+	if s.protocolVersion > 0 {
+		// the MLS state was lost with the previous connection, this sends a new key package to rejoin the group
+		s.sendLocked(opOnSelectProtocolAck, binary.BigEndian.AppendUint16(nil, s.protocolVersion))
+	}
+}
+
+func (s *session) disconnected() {
+	s.mu.Lock()
+	s.conn, s.remoteID = nil, 0
+	s.mu.Unlock()
+	s.ready.Store(false)
+}
+
+func (s *session) remote() (*conn, uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.remoteLocked()
+}
+
+func (s *session) remoteLocked() (*conn, uint32, error) {
+	if s.closed {
+		return nil, 0, ErrClosed
+	}
+	if s.conn == nil {
+		return nil, 0, ErrDisconnected
+	}
+	return s.conn, s.remoteID, nil
+}
+
+func (s *session) call(op op, payload []byte) (*decoder, error) {
+	cn, remoteID, err := s.remote()
+	if err != nil {
+		return nil, err
+	}
+	return s.do(cn, remoteID, op, payload)
+}
+
+func (s *session) do(cn *conn, remoteID uint32, op op, payload []byte) (*decoder, error) {
+	d, ready, err := s.client.call(cn, remoteID, op, payload)
+	s.ready.Store(ready)
+	return d, err
+}
+
+// sendLocked calls an operation without result and logs its error. While disconnected the state is restored
+// after reconnecting, so the call is dropped.
+func (s *session) sendLocked(op op, payload []byte) {
+	cn, remoteID, err := s.remoteLocked()
+	if err == nil {
+		_, err = s.do(cn, remoteID, op, payload)
+	}
+	if errors.Is(err, ErrDisconnected) {
+		s.logger.Debug("dropped sidecar call while disconnected", slog.String("op", op.String()))
+	} else if err != nil {
+		s.logger.Error("sidecar call failed", slog.String("op", op.String()), slog.Any("err", err))
+	}
+}
+
+func (s *session) MaxSupportedProtocolVersion() int {
+	s.mu.Lock()
+	maxProtocolVersion := s.maxProtocolVersion
+	s.mu.Unlock()
+	if maxProtocolVersion == 0 {
+		s.client.mu.Lock()
+		maxProtocolVersion = s.client.maxProtocolVersion
+		s.client.mu.Unlock()
+	}
+	return maxProtocolVersion
+}
+
+func (s *session) Ready() bool {
+	return s.ready.Load()
+}
+
+func (s *session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	cn, remoteID, err := s.remoteLocked()
+	s.closed = true
+	s.client.remove(s)
+	s.ready.Store(false)
+	if err != nil {
+		// the sidecar closes the sessions of a lost connection itself
+		return nil
+	}
+
+	if _, err = s.do(cn, remoteID, opClose, nil); errors.Is(err, ErrDisconnected) || errors.Is(err, ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *session) SetChannelID(channelID godave.ChannelID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channelID = channelID
+	s.sendLocked(opSetChannelID, binary.BigEndian.AppendUint64(nil, uint64(channelID)))
+}
+
+func (s *session) AssignSsrcToCodec(ssrc uint32, codec godave.Codec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ssrcs[ssrc] = codec
+	s.sendLocked(opAssignSsrcToCodec, binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, ssrc), uint32(codec)))
+}
+
+func (s *session) MaxEncryptedFrameSize(frameSize int) int {
+	d, err := s.call(opMaxEncryptedFrameSize, binary.BigEndian.AppendUint32(nil, uint32(frameSize)))
+	if err != nil {
+		return frameSize
+	}
+	return int(d.uint32())
+}
+
+func (s *session) Encrypt(ssrc uint32, frame []byte, encryptedFrame []byte) (int, error) {
+	buf := payloadPool.Get().(*[]byte)
+	payload := appendBytes(binary.BigEndian.AppendUint32((*buf)[:0], ssrc), frame)
+	d, err := s.call(opEncrypt, payload)
+	*buf = payload
+	payloadPool.Put(buf)
+	if err != nil {
+		return 0, err
+	}
+	if len(d.b) > len(encryptedFrame) {
+		return 0, io.ErrShortBuffer
+	}
+	return copy(encryptedFrame, d.b), nil
+}
+
+func (s *session) MaxDecryptedFrameSize(userID godave.UserID, frameSize int) int {
+	d, err := s.call(opMaxDecryptedFrameSize, binary.BigEndian.AppendUint32(appendString(nil, string(userID)), uint32(frameSize)))
+	if err != nil {
+		return frameSize
+	}
+	return int(d.uint32())
+}
+
+func (s *session) Decrypt(userID godave.UserID, frame []byte, decryptedFrame []byte) (int, error) {
+	buf := payloadPool.Get().(*[]byte)
+	payload := appendBytes(appendString((*buf)[:0], string(userID)), frame)
+	d, err := s.call(opDecrypt, payload)
+	*buf = payload
+	payloadPool.Put(buf)
+	if err != nil {
+		return 0, err
+	}
+	if len(d.b) > len(decryptedFrame) {
+		return 0, io.ErrShortBuffer
+	}
+	return copy(decryptedFrame, d.b), nil
+}
+
+func (s *session) AddUser(userID godave.UserID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = struct{}{}
+	s.sendLocked(opAddUser, appendString(nil, string(userID)))
+}
+
+func (s *session) RemoveUser(userID godave.UserID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, userID)
+	s.sendLocked(opRemoveUser, appendString(nil, string(userID)))
+}
+
+func (s *session) OnSelectProtocolAck(protocolVersion uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolVersion = protocolVersion
+	s.sendLocked(opOnSelectProtocolAck, binary.BigEndian.AppendUint16(nil, protocolVersion))
+}
+
+func (s *session) OnDavePrepareTransition(transitionID uint16, protocolVersion uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolVersion = protocolVersion
+	s.sendLocked(opOnDavePrepareTransition, binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, transitionID), protocolVersion))
+}
+
+func (s *session) OnDaveExecuteTransition(transitionID uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendLocked(opOnDaveExecuteTransition, binary.BigEndian.AppendUint16(nil, transitionID))
+}
+
+func (s *session) OnDavePrepareEpoch(epoch int, protocolVersion uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protocolVersion = protocolVersion
+	s.sendLocked(opOnDavePrepareEpoch, binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint64(nil, uint64(epoch)), protocolVersion))
+}
+
+func (s *session) OnDaveMLSExternalSenderPackage(externalSenderPackage []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.externalSender = bytes.Clone(externalSenderPackage)
+	s.sendLocked(opOnDaveMLSExternalSenderPackage, appendBytes(nil, externalSenderPackage))
+}
+
+func (s *session) OnDaveMLSProposals(proposals []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendLocked(opOnDaveMLSProposals, appendBytes(nil, proposals))
+}
+
+func (s *session) OnDaveMLSPrepareCommitTransition(transitionID uint16, commitMessage []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendLocked(opOnDaveMLSPrepareCommitTransition, appendBytes(binary.BigEndian.AppendUint16(nil, transitionID), commitMessage))
+}
+
+func (s *session) OnDaveMLSWelcome(transitionID uint16, welcomeMessage []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendLocked(opOnDaveMLSWelcome, appendBytes(binary.BigEndian.AppendUint16(nil, transitionID), welcomeMessage))
+}
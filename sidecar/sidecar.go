@@ -0,0 +1,28 @@
+// Package sidecar runs godave sessions out of process. A Server hosts sessions of any godave.SessionCreateFunc,
+// usually golibdave, and serves them over a Unix socket to Clients in other processes, which keeps libdave and cgo
+// out of the process handling voice connections.
+//
+// Run the server with the godave-sidecar command of the golibdave module:
+//
+//	go run github.com/disgoorg/godave/golibdave/cmd/godave-sidecar -socket /run/godave.sock
+//
+// and use Client.CreateSession as godave.SessionCreateFunc:
+//
+//	client, err := sidecar.NewClient(sidecar.WithSocketPath("/run/godave.sock"))
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	createSession := client.CreateSession
+//
+// All calls of a session, including the callbacks, are forwarded over the connection. Encrypt and Decrypt send
+// the frame in a single length-prefixed message and copy the result into the buffer of the caller, their payload
+// buffers are pooled.
+//
+// The sidecar closes the sessions of a client when its connection is lost. Until the client has reconnected, calls
+// fail with ErrDisconnected and Ready returns false, so frames are not sent or received. After reconnecting, the
+// client recreates its sessions and restores their channel, SSRCs, users and the external sender package of the
+// voice gateway. Sessions which negotiated DAVE then send a new key package and are added to the MLS group with
+// the next commit of the voice gateway.
+package sidecar
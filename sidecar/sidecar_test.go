@@ -0,0 +1,251 @@
+package sidecar
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/godave"
+)
+
+// fakeSession appends 0xFF to encrypted frames and becomes ready with the first welcome.
+type fakeSession struct {
+	godave.Session
+	callbacks godave.Callbacks
+
+	mu        sync.Mutex
+	userID    godave.UserID
+	channelID godave.ChannelID
+	users     []godave.UserID
+	ready     bool
+	// mls records the MLS calls in order.
+	mls []string
+}
+
+func (s *fakeSession) MaxSupportedProtocolVersion() int { return 1 }
+
+func (s *fakeSession) Ready() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ready
+}
+
+func (s *fakeSession) Close() error { return nil }
+
+func (s *fakeSession) SetChannelID(channelID godave.ChannelID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channelID = channelID
+}
+
+func (s *fakeSession) AddUser(userID godave.UserID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, userID)
+}
+
+func (s *fakeSession) MaxEncryptedFrameSize(frameSize int) int { return frameSize + 1 }
+
+func (s *fakeSession) Encrypt(_ uint32, frame []byte, encryptedFrame []byte) (int, error) {
+	if !s.Ready() {
+		return 0, godave.ErrNotReady
+	}
+	return copy(encryptedFrame, append(frame, 0xFF)), nil
+}
+
+func (s *fakeSession) OnDaveMLSExternalSenderPackage(externalSenderPackage []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mls = append(s.mls, "external sender: "+string(externalSenderPackage))
+}
+
+func (s *fakeSession) OnSelectProtocolAck(uint16) {
+	s.mu.Lock()
+	s.mls = append(s.mls, "select protocol ack")
+	s.mu.Unlock()
+	_ = s.callbacks.SendMLSKeyPackage([]byte("key package " + s.userID))
+}
+
+func (s *fakeSession) OnDaveMLSWelcome(transitionID uint16, _ []byte) {
+	s.mu.Lock()
+	s.ready = true
+	s.mu.Unlock()
+	_ = s.callbacks.SendReadyForTransition(transitionID)
+}
+
+// callbacks sends the name and payload of every callback to a channel.
+type callbacks chan string
+
+func (c callbacks) SendMLSKeyPackage(mlsKeyPackage []byte) error {
+	c <- "key package: " + string(mlsKeyPackage)
+	return nil
+}
+
+func (c callbacks) SendMLSCommitWelcome([]byte) error {
+	return errors.New("unexpected commit welcome")
+}
+
+func (c callbacks) SendReadyForTransition(transitionID uint16) error {
+	c <- "ready for transition"
+	return nil
+}
+
+func (c callbacks) SendInvalidCommitWelcome(uint16) error {
+	return errors.New("unexpected invalid commit welcome")
+}
+
+func (c callbacks) expect(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case got := <-c:
+		if got != want {
+			t.Fatalf("expected callback %q, got %q", want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for callback %q", want)
+	}
+}
+
+type testServer struct {
+	*Server
+	sessions chan *fakeSession
+}
+
+func startServer(t *testing.T, socket string) *testServer {
+	t.Helper()
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{sessions: make(chan *fakeSession, 8)}
+	s.Server = NewServer(func(logger *slog.Logger, userID godave.UserID, callbacks godave.Callbacks) godave.Session {
+		session := &fakeSession{
+			Session:   godave.NewNoopSession(logger, userID, callbacks),
+			callbacks: callbacks,
+			userID:    userID,
+		}
+		s.sessions <- session
+		return session
+	}, slog.New(slog.DiscardHandler))
+	go s.Serve(l)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func socketPath(t *testing.T) string {
+	// t.TempDir can exceed the maximum length of socket paths
+	dir, err := os.MkdirTemp("", "sidecar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, "s.sock")
+}
+
+func newClient(t *testing.T, socket string) *Client {
+	t.Helper()
+	client, err := NewClient(WithSocketPath(socket), WithLogger(slog.New(slog.DiscardHandler)), WithReconnectDelay(10*time.Millisecond, 50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestSession(t *testing.T) {
+	socket := socketPath(t)
+	startServer(t, socket)
+	client := newClient(t, socket)
+
+	cb := make(callbacks, 8)
+	session := client.CreateSession(slog.New(slog.DiscardHandler), "1", cb)
+	if v := session.MaxSupportedProtocolVersion(); v != 1 {
+		t.Errorf("expected max protocol version 1, got %d", v)
+	}
+
+	frame := []byte("frame")
+	encryptedFrame := make([]byte, session.MaxEncryptedFrameSize(len(frame)))
+	if _, err := session.Encrypt(1, frame, encryptedFrame); !errors.Is(err, godave.ErrNotReady) {
+		t.Errorf("expected ErrNotReady, got %v", err)
+	}
+
+	session.OnSelectProtocolAck(1)
+	cb.expect(t, "key package: key package 1")
+	session.OnDaveMLSWelcome(2, []byte("welcome"))
+	cb.expect(t, "ready for transition")
+	if !session.Ready() {
+		t.Error("expected session to be ready")
+	}
+
+	n, err := session.Encrypt(1, frame, encryptedFrame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append(frame, 0xFF); !bytes.Equal(encryptedFrame[:n], want) {
+		t.Errorf("expected encrypted frame %x, got %x", want, encryptedFrame[:n])
+	}
+	if _, err = session.Encrypt(1, frame, make([]byte, 2)); err == nil {
+		t.Error("expected error for short buffer")
+	}
+
+	decryptedFrame := make([]byte, session.MaxDecryptedFrameSize("2", len(frame)))
+	if n, err = session.Decrypt("2", frame, decryptedFrame); err != nil || !bytes.Equal(decryptedFrame[:n], frame) {
+		t.Errorf("expected passthrough decrypt, got %x, %v", decryptedFrame[:n], err)
+	}
+
+	if err = session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = session.Encrypt(1, frame, encryptedFrame); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	socket := socketPath(t)
+	server := startServer(t, socket)
+	client := newClient(t, socket)
+
+	cb := make(callbacks, 8)
+	session := client.CreateSession(slog.New(slog.DiscardHandler), "1", cb)
+	<-server.sessions
+	session.SetChannelID(5)
+	session.AddUser("2")
+	session.AddUser("3")
+	session.RemoveUser("3")
+	session.OnSelectProtocolAck(1)
+	cb.expect(t, "key package: key package 1")
+	session.OnDaveMLSExternalSenderPackage([]byte("sender"))
+	session.OnDaveMLSWelcome(2, []byte("welcome"))
+	cb.expect(t, "ready for transition")
+
+	_ = server.Close()
+	server = startServer(t, socket)
+
+	// the restored session sends a new key package to rejoin the group
+	cb.expect(t, "key package: key package 1")
+	restored := <-server.sessions
+	restored.mu.Lock()
+	channelID, users, mls := restored.channelID, restored.users, restored.mls
+	restored.mu.Unlock()
+	if channelID != 5 || len(users) != 1 || users[0] != "2" {
+		t.Errorf("expected restored channel 5 and user 2, got channel %d and users %v", channelID, users)
+	}
+	// the voice gateway does not resend the external sender, the new MLS state needs it before the key package
+	if want := []string{"external sender: sender", "select protocol ack"}; !slices.Equal(mls, want) {
+		t.Errorf("expected MLS calls %q, got %q", want, mls)
+	}
+	if session.Ready() {
+		t.Error("expected restored session not to be ready before the welcome")
+	}
+	if !client.Connected() {
+		t.Error("expected client to be connected")
+	}
+}
@@ -0,0 +1,46 @@
+package tofu
+
+import (
+	"log/slog"
+	"time"
+)
+
+// DefaultConfig returns a Config with sensible defaults.
+func DefaultConfig() *Config {
+	return &Config{
+		Logger: slog.Default(),
+		Clock:  time.Now,
+	}
+}
+
+// Config is the configuration used by a Verifier.
+type Config struct {
+	// Logger logs store errors in HandleRosterUpdate.
+	Logger *slog.Logger
+	// Clock returns the current time used for event timestamps.
+	Clock func() time.Time
+}
+
+// ConfigOpt is a type alias for a function that takes a Config and is used to configure your Verifier.
+type ConfigOpt func(config *Config)
+
+// Apply applies the given ConfigOpt(s) to the Config.
+func (c *Config) Apply(opts []ConfigOpt) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// WithLogger sets the logger used to log store errors in HandleRosterUpdate.
+func WithLogger(logger *slog.Logger) ConfigOpt {
+	return func(config *Config) {
+		config.Logger = logger
+	}
+}
+
+// WithClock sets the function returning the current time used for event timestamps.
+func WithClock(clock func() time.Time) ConfigOpt {
+	return func(config *Config) {
+		config.Clock = clock
+	}
+}
@@ -0,0 +1,129 @@
+package tofu
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/disgoorg/godave"
+)
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore returns a new FileStore persisting the pinned keys as JSON to the given path.
+// The file is created on the first Pin if it does not exist yet.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path: path,
+		keys: make(map[godave.UserID][]byte),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key store: %w", err)
+	}
+
+	if err = json.Unmarshal(data, &s.keys); err != nil {
+		return nil, fmt.Errorf("failed to decode key store %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// FileStore is a Store persisting the pinned keys as a JSON object mapping user IDs to base64 encoded keys.
+type FileStore struct {
+	path string
+	mu   sync.RWMutex
+	keys map[godave.UserID][]byte
+}
+
+func (s *FileStore) Get(userID godave.UserID) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return bytes.Clone(s.keys[userID]), nil
+}
+
+func (s *FileStore) Pin(userID godave.UserID, key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldKey, ok := s.keys[userID]
+	s.keys[userID] = bytes.Clone(key)
+	if err := s.save(); err != nil {
+		if ok {
+			s.keys[userID] = oldKey
+		} else {
+			delete(s.keys, userID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) PinIfAbsent(userID godave.UserID, key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pinnedKey, ok := s.keys[userID]; ok {
+		return bytes.Clone(pinnedKey), nil
+	}
+
+	s.keys[userID] = bytes.Clone(key)
+	if err := s.save(); err != nil {
+		delete(s.keys, userID)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *FileStore) Forget(userID godave.UserID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldKey, ok := s.keys[userID]
+	if !ok {
+		return nil
+	}
+
+	delete(s.keys, userID)
+	if err := s.save(); err != nil {
+		s.keys[userID] = oldKey
+		return err
+	}
+	return nil
+}
+
+// save atomically replaces the key store file. It must be called with mu held.
+func (s *FileStore) save() error {
+	data, err := json.MarshalIndent(s.keys, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to encode key store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write key store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write key store: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write key store: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write key store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package tofu
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/disgoorg/godave"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys: make(map[godave.UserID][]byte),
+	}
+}
+
+// MemoryStore is a Store keeping the pinned keys in memory.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys map[godave.UserID][]byte
+}
+
+func (s *MemoryStore) Get(userID godave.UserID) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return bytes.Clone(s.keys[userID]), nil
+}
+
+func (s *MemoryStore) Pin(userID godave.UserID, key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[userID] = bytes.Clone(key)
+	return nil
+}
+
+func (s *MemoryStore) PinIfAbsent(userID godave.UserID, key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pinnedKey, ok := s.keys[userID]; ok {
+		return bytes.Clone(pinnedKey), nil
+	}
+	s.keys[userID] = bytes.Clone(key)
+	return nil, nil
+}
+
+func (s *MemoryStore) Forget(userID godave.UserID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, userID)
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package tofu implements trust-on-first-use pinning of DAVE identity keys.
+//
+// The first signature key seen for a user is pinned in a Store, subsequent keys are compared against it,
+// similar to SSH known_hosts. A Verifier can be plugged into a session as godave.RosterUpdateHandler:
+//
+//	verifier := tofu.NewVerifier(store, func(event godave.SecurityEvent) {
+//		// warn moderators
+//	})
+//	createSession, err := golibdave.NewSessionCreateFunc(golibdave.WithRosterUpdateHandler(verifier.HandleRosterUpdate))
+package tofu
+
+import (
+	"bytes"
+	"log/slog"
+
+	"github.com/disgoorg/godave"
+)
+
+// Store persists the pinned signature key of each user.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the key pinned for the user, or nil if no key is pinned yet.
+	Get(userID godave.UserID) ([]byte, error)
+	// Pin pins the key for the user, replacing any previously pinned key.
+	Pin(userID godave.UserID, key []byte) error
+	// PinIfAbsent atomically pins the key for the user if no key is pinned yet.
+	// It returns the key pinned before, or nil if the key has been pinned.
+	PinIfAbsent(userID godave.UserID, key []byte) ([]byte, error)
+	// Forget removes the key pinned for the user.
+	Forget(userID godave.UserID) error
+}
+
+// Result is the outcome of verifying a key against a Store.
+type Result int
+
+const (
+	// ResultNew means no key was pinned for the user and the key has been pinned.
+	ResultNew Result = iota + 1
+	// ResultMatch means the key matches the pinned key.
+	ResultMatch
+	// ResultChanged means the key differs from the pinned key. The pinned key is kept.
+	ResultChanged
+)
+
+func (r Result) String() string {
+	switch r {
+	case ResultNew:
+		return "new"
+	case ResultMatch:
+		return "match"
+	case ResultChanged:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// NewVerifier returns a new Verifier using the given Store configured with the given ConfigOpt(s).
+// The handler is called with a godave.IdentityKeyChangedEvent for every key change and may be nil.
+func NewVerifier(store Store, handler godave.SecurityEventHandler, opts ...ConfigOpt) *Verifier {
+	config := DefaultConfig()
+	config.Apply(opts)
+
+	return &Verifier{
+		store:   store,
+		handler: handler,
+		config:  *config,
+	}
+}
+
+// Verifier verifies signature keys against the keys pinned in a Store.
+type Verifier struct {
+	store   Store
+	handler godave.SecurityEventHandler
+	config  Config
+}
+
+// Verify verifies the key of the user, pinning it if the user has no pinned key yet.
+func (v *Verifier) Verify(userID godave.UserID, key []byte) (Result, error) {
+	pinnedKey, err := v.store.PinIfAbsent(userID, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if pinnedKey == nil {
+		return ResultNew, nil
+	}
+
+	if bytes.Equal(pinnedKey, key) {
+		return ResultMatch, nil
+	}
+
+	if v.handler != nil {
+		v.handler(&godave.IdentityKeyChangedEvent{
+			UserID:     userID,
+			PinnedKey:  pinnedKey,
+			Key:        key,
+			DetectedAt: v.config.Clock(),
+		})
+	}
+	return ResultChanged, nil
+}
+
+// Accept pins the key of the user, accepting a changed key.
+func (v *Verifier) Accept(userID godave.UserID, key []byte) error {
+	return v.store.Pin(userID, key)
+}
+
+// HandleRosterUpdate verifies all added and changed keys of the godave.RosterUpdate.
+// It can be used as godave.RosterUpdateHandler.
+func (v *Verifier) HandleRosterUpdate(update godave.RosterUpdate) {
+	for userID, key := range update.Added {
+		v.verify(userID, key)
+	}
+	for userID, change := range update.Changed {
+		v.verify(userID, change.New)
+	}
+}
+
+func (v *Verifier) verify(userID godave.UserID, key []byte) {
+	if _, err := v.Verify(userID, key); err != nil {
+		v.config.Logger.Error("failed to verify identity key", slog.String("user_id", string(userID)), slog.Any("err", err))
+	}
+}
@@ -0,0 +1,124 @@
+package tofu
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/godave"
+)
+
+var testTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestVerifier(t *testing.T) {
+	var events []godave.SecurityEvent
+	verifier := NewVerifier(NewMemoryStore(), func(event godave.SecurityEvent) {
+		events = append(events, event)
+	}, WithClock(func() time.Time { return testTime }))
+
+	tests := []struct {
+		key      string
+		expected Result
+	}{
+		{key: "key-1", expected: ResultNew},
+		{key: "key-1", expected: ResultMatch},
+		{key: "key-2", expected: ResultChanged},
+		{key: "key-1", expected: ResultMatch},
+	}
+	for _, tt := range tests {
+		res, err := verifier.Verify("1234", []byte(tt.key))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res != tt.expected {
+			t.Errorf("verifying %s: expected %s, got %s", tt.key, tt.expected, res)
+		}
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0].(*godave.IdentityKeyChangedEvent)
+	if event.UserID != "1234" || string(event.PinnedKey) != "key-1" || string(event.Key) != "key-2" || !event.DetectedAt.Equal(testTime) {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestVerifierConcurrentFirstSeen(t *testing.T) {
+	store := NewMemoryStore()
+	verifier := NewVerifier(store, nil)
+
+	const verifiers = 16
+	results := make([]Result, verifiers)
+	var wg sync.WaitGroup
+	for i := range verifiers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := verifier.Verify("1234", fmt.Appendf(nil, "key-%d", i))
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	pinnedKey, _ := store.Get("1234")
+	var newResults int
+	for i, res := range results {
+		switch res {
+		case ResultNew:
+			newResults++
+			if string(pinnedKey) != fmt.Sprintf("key-%d", i) {
+				t.Errorf("expected key-%d to be pinned, got %q", i, pinnedKey)
+			}
+		case ResultChanged:
+		default:
+			t.Errorf("expected new or changed, got %s", res)
+		}
+	}
+	if newResults != 1 {
+		t.Errorf("expected exactly 1 new key, got %d", newResults)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_keys.json")
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = store.Pin("1234", []byte("key-1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = store.Pin("5678", []byte("key-2")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = store.Forget("5678"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pinnedKey, err := store.PinIfAbsent("1234", []byte("key-3")); err != nil || string(pinnedKey) != "key-1" {
+		t.Fatalf("expected key-1 to stay pinned, got %q, %v", pinnedKey, err)
+	}
+	if pinnedKey, err := store.PinIfAbsent("9012", []byte("key-4")); err != nil || pinnedKey != nil {
+		t.Fatalf("expected key-4 to be pinned, got %q, %v", pinnedKey, err)
+	}
+
+	store, err = NewFileStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key, _ := store.Get("1234"); string(key) != "key-1" {
+		t.Errorf("expected key-1, got %q", key)
+	}
+	if key, _ := store.Get("5678"); key != nil {
+		t.Errorf("expected no key, got %q", key)
+	}
+	if key, _ := store.Get("9012"); string(key) != "key-4" {
+		t.Errorf("expected key-4, got %q", key)
+	}
+}
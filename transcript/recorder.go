@@ -0,0 +1,140 @@
+package transcript
+
+import (
+	"log/slog"
+
+	"github.com/disgoorg/godave"
+)
+
+// NewSessionCreateFunc returns a godave.SessionCreateFunc recording the sessions created by create to w.
+// Encrypt and Decrypt are not recorded. Optional interfaces such as godave.BatchSession are not forwarded,
+// the helper functions fall back to the godave.Session methods.
+func NewSessionCreateFunc(create godave.SessionCreateFunc, w *Writer) godave.SessionCreateFunc {
+	return func(logger *slog.Logger, selfUserID godave.UserID, callbacks godave.Callbacks) godave.Session {
+		r := &recorder{
+			w:      w,
+			id:     w.nextSession(),
+			logger: logger,
+		}
+		r.write(Event{Type: EventTypeCall, Method: MethodNewSession, UserID: selfUserID})
+
+		s := &session{recorder: r}
+		s.Session = create(logger, selfUserID, &recordingCallbacks{recorder: r, callbacks: callbacks})
+		return s
+	}
+}
+
+type recorder struct {
+	w      *Writer
+	id     int
+	logger *slog.Logger
+}
+
+func (r *recorder) write(event Event) {
+	event.Session = r.id
+	if err := r.w.Write(event); err != nil {
+		r.logger.Error("failed to write transcript event", slog.String("method", event.Method), slog.Any("err", err))
+	}
+}
+
+func (r *recorder) call(event Event) {
+	event.Type = EventTypeCall
+	r.write(event)
+}
+
+func (r *recorder) callback(event Event, err error) error {
+	event.Type = EventTypeCallback
+	if err != nil {
+		event.Error = err.Error()
+	}
+	r.write(event)
+	return err
+}
+
+type recordingCallbacks struct {
+	*recorder
+	callbacks godave.Callbacks
+}
+
+func (c *recordingCallbacks) SendMLSKeyPackage(mlsKeyPackage []byte) error {
+	return c.callback(Event{Method: "SendMLSKeyPackage", Payload: mlsKeyPackage}, c.callbacks.SendMLSKeyPackage(mlsKeyPackage))
+}
+
+func (c *recordingCallbacks) SendMLSCommitWelcome(mlsCommitWelcome []byte) error {
+	return c.callback(Event{Method: "SendMLSCommitWelcome", Payload: mlsCommitWelcome}, c.callbacks.SendMLSCommitWelcome(mlsCommitWelcome))
+}
+
+func (c *recordingCallbacks) SendReadyForTransition(transitionID uint16) error {
+	return c.callback(Event{Method: "SendReadyForTransition", TransitionID: &transitionID}, c.callbacks.SendReadyForTransition(transitionID))
+}
+
+func (c *recordingCallbacks) SendInvalidCommitWelcome(transitionID uint16) error {
+	return c.callback(Event{Method: "SendInvalidCommitWelcome", TransitionID: &transitionID}, c.callbacks.SendInvalidCommitWelcome(transitionID))
+}
+
+// session records the calls into the wrapped godave.Session. Calls are recorded before they are forwarded,
+// so callbacks invoked during a call follow it in the transcript.
+type session struct {
+	godave.Session
+	*recorder
+}
+
+func (s *session) Close() error {
+	s.call(Event{Method: "Close"})
+	return s.Session.Close()
+}
+
+func (s *session) SetChannelID(channelID godave.ChannelID) {
+	s.call(Event{Method: "SetChannelID", ChannelID: channelID})
+	s.Session.SetChannelID(channelID)
+}
+
+func (s *session) AddUser(userID godave.UserID) {
+	s.call(Event{Method: "AddUser", UserID: userID})
+	s.Session.AddUser(userID)
+}
+
+func (s *session) RemoveUser(userID godave.UserID) {
+	s.call(Event{Method: "RemoveUser", UserID: userID})
+	s.Session.RemoveUser(userID)
+}
+
+func (s *session) OnSelectProtocolAck(protocolVersion uint16) {
+	s.call(Event{Method: "OnSelectProtocolAck", ProtocolVersion: &protocolVersion})
+	s.Session.OnSelectProtocolAck(protocolVersion)
+}
+
+func (s *session) OnDavePrepareTransition(transitionID uint16, protocolVersion uint16) {
+	s.call(Event{Method: "OnDavePrepareTransition", TransitionID: &transitionID, ProtocolVersion: &protocolVersion})
+	s.Session.OnDavePrepareTransition(transitionID, protocolVersion)
+}
+
+func (s *session) OnDaveExecuteTransition(protocolVersion uint16) {
+	s.call(Event{Method: "OnDaveExecuteTransition", ProtocolVersion: &protocolVersion})
+	s.Session.OnDaveExecuteTransition(protocolVersion)
+}
+
+func (s *session) OnDavePrepareEpoch(epoch int, protocolVersion uint16) {
+	s.call(Event{Method: "OnDavePrepareEpoch", Epoch: &epoch, ProtocolVersion: &protocolVersion})
+	s.Session.OnDavePrepareEpoch(epoch, protocolVersion)
+}
+
+func (s *session) OnDaveMLSExternalSenderPackage(externalSenderPackage []byte) {
+	s.call(Event{Method: "OnDaveMLSExternalSenderPackage", Payload: externalSenderPackage})
+	s.Session.OnDaveMLSExternalSenderPackage(externalSenderPackage)
+}
+
+func (s *session) OnDaveMLSProposals(proposals []byte) {
+	s.call(Event{Method: "OnDaveMLSProposals", Payload: proposals})
+	s.Session.OnDaveMLSProposals(proposals)
+}
+
+func (s *session) OnDaveMLSPrepareCommitTransition(transitionID uint16, commitMessage []byte) {
+	s.call(Event{Method: "OnDaveMLSPrepareCommitTransition", TransitionID: &transitionID, Payload: commitMessage})
+	s.Session.OnDaveMLSPrepareCommitTransition(transitionID, commitMessage)
+}
+
+func (s *session) OnDaveMLSWelcome(transitionID uint16, welcomeMessage []byte) {
+	s.call(Event{Method: "OnDaveMLSWelcome", TransitionID: &transitionID, Payload: welcomeMessage})
+	s.Session.OnDaveMLSWelcome(transitionID, welcomeMessage)
+}
@@ -0,0 +1,206 @@
+package transcript
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/disgoorg/godave"
+)
+
+var (
+	ErrUnknownMethod  = errors.New("transcript: unknown method")
+	ErrUnknownSession = errors.New("transcript: event of a session which was not created")
+)
+
+// Diff is a call after which the replayed session invoked different callbacks than the recorded session.
+type Diff struct {
+	Call     Event
+	Expected []Event
+	Actual   []Event
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("session %d %s: expected [%s], got [%s]", d.Call.Session, describe(d.Call), describeAll(d.Expected), describeAll(d.Actual))
+}
+
+// Replay creates a session with create for every session of the transcript, feeds the recorded calls into it and
+// returns the calls after which the callbacks differ. Callbacks are compared by method and transition ID.
+//
+// Payloads are not compared, as MLS key packages and commits contain fresh random keys. For the same reason a
+// replayed session can't process a welcome or commit encrypted to the keys of the recorded session, so replays
+// reproduce failures independent of the group secrets, such as rejected proposals or transitions.
+func Replay(create godave.SessionCreateFunc, logger *slog.Logger, events []Event) ([]Diff, error) {
+	sessions := make(map[int]*replayedSession)
+	defer func() {
+		for _, s := range sessions {
+			if !s.closed {
+				_ = s.session.Close()
+			}
+		}
+	}()
+
+	var diffs []Diff
+	for i, event := range events {
+		if event.Type != EventTypeCall {
+			continue
+		}
+		expected := expectedCallbacks(events[i+1:], event.Session)
+
+		s, ok := sessions[event.Session]
+		if event.Method == MethodNewSession {
+			s = &replayedSession{}
+			s.session = create(logger, event.UserID, &s.callbacks)
+			sessions[event.Session] = s
+		} else if !ok {
+			return diffs, fmt.Errorf("%w: session %d", ErrUnknownSession, event.Session)
+		} else if err := s.call(event); err != nil {
+			return diffs, err
+		}
+
+		actual := s.callbacks.events
+		s.callbacks.events = nil
+		if !equalCallbacks(expected, actual) {
+			diffs = append(diffs, Diff{Call: event, Expected: expected, Actual: actual})
+		}
+	}
+	return diffs, nil
+}
+
+// expectedCallbacks returns the callbacks of the session recorded until its next call.
+func expectedCallbacks(events []Event, session int) []Event {
+	var callbacks []Event
+	for _, event := range events {
+		if event.Session != session {
+			continue
+		}
+		if event.Type == EventTypeCall {
+			break
+		}
+		callbacks = append(callbacks, event)
+	}
+	return callbacks
+}
+
+func equalCallbacks(expected []Event, actual []Event) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if expected[i].Method != actual[i].Method || !equalPtr(expected[i].TransitionID, actual[i].TransitionID) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalPtr[T comparable](a *T, b *T) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+type replayedSession struct {
+	session   godave.Session
+	callbacks collectingCallbacks
+	closed    bool
+}
+
+func (s *replayedSession) call(event Event) error {
+	session := s.session
+	switch event.Method {
+	case "Close":
+		s.closed = true
+		return session.Close()
+	case "SetChannelID":
+		session.SetChannelID(event.ChannelID)
+	case "AddUser":
+		session.AddUser(event.UserID)
+	case "RemoveUser":
+		session.RemoveUser(event.UserID)
+	case "OnSelectProtocolAck":
+		session.OnSelectProtocolAck(value(event.ProtocolVersion))
+	case "OnDavePrepareTransition":
+		session.OnDavePrepareTransition(value(event.TransitionID), value(event.ProtocolVersion))
+	case "OnDaveExecuteTransition":
+		session.OnDaveExecuteTransition(value(event.ProtocolVersion))
+	case "OnDavePrepareEpoch":
+		session.OnDavePrepareEpoch(value(event.Epoch), value(event.ProtocolVersion))
+	case "OnDaveMLSExternalSenderPackage":
+		session.OnDaveMLSExternalSenderPackage(event.Payload)
+	case "OnDaveMLSProposals":
+		session.OnDaveMLSProposals(event.Payload)
+	case "OnDaveMLSPrepareCommitTransition":
+		session.OnDaveMLSPrepareCommitTransition(value(event.TransitionID), event.Payload)
+	case "OnDaveMLSWelcome":
+		session.OnDaveMLSWelcome(value(event.TransitionID), event.Payload)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownMethod, event.Method)
+	}
+	return nil
+}
+
+func value[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
+// collectingCallbacks collects the callbacks of a replayed session.
+type collectingCallbacks struct {
+	events []Event
+}
+
+func (c *collectingCallbacks) SendMLSKeyPackage(mlsKeyPackage []byte) error {
+	c.events = append(c.events, Event{Type: EventTypeCallback, Method: "SendMLSKeyPackage", Payload: mlsKeyPackage})
+	return nil
+}
+
+func (c *collectingCallbacks) SendMLSCommitWelcome(mlsCommitWelcome []byte) error {
+	c.events = append(c.events, Event{Type: EventTypeCallback, Method: "SendMLSCommitWelcome", Payload: mlsCommitWelcome})
+	return nil
+}
+
+func (c *collectingCallbacks) SendReadyForTransition(transitionID uint16) error {
+	c.events = append(c.events, Event{Type: EventTypeCallback, Method: "SendReadyForTransition", TransitionID: &transitionID})
+	return nil
+}
+
+func (c *collectingCallbacks) SendInvalidCommitWelcome(transitionID uint16) error {
+	c.events = append(c.events, Event{Type: EventTypeCallback, Method: "SendInvalidCommitWelcome", TransitionID: &transitionID})
+	return nil
+}
+
+// describe returns the method and the identifying arguments of the event.
+func describe(event Event) string {
+	var args []string
+	if event.UserID != "" {
+		args = append(args, "user "+string(event.UserID))
+	}
+	if event.ChannelID != 0 {
+		args = append(args, "channel "+strconv.FormatUint(uint64(event.ChannelID), 10))
+	}
+	if event.TransitionID != nil {
+		args = append(args, "transition "+strconv.Itoa(int(*event.TransitionID)))
+	}
+	if event.ProtocolVersion != nil {
+		args = append(args, "version "+strconv.Itoa(int(*event.ProtocolVersion)))
+	}
+	if event.Epoch != nil {
+		args = append(args, "epoch "+strconv.Itoa(*event.Epoch))
+	}
+	if len(event.Payload) > 0 {
+		args = append(args, strconv.Itoa(len(event.Payload))+" bytes")
+	}
+	return event.Method + "(" + strings.Join(args, ", ") + ")"
+}
+
+func describeAll(events []Event) string {
+	descriptions := make([]string, len(events))
+	for i, event := range events {
+		descriptions[i] = describe(event)
+	}
+	return strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,108 @@
+// Package transcript records the DAVE protocol events of sessions to JSONL transcripts and replays them.
+//
+// A recording session created with NewSessionCreateFunc writes every call of the voice gateway into the session,
+// such as OnDaveMLSProposals or AddUser, and every godave.Callbacks invocation of the session as one Event per line:
+//
+//	createSession = transcript.NewSessionCreateFunc(createSession, transcript.NewWriter(file))
+//
+// Replay feeds the calls of a transcript into fresh sessions and compares the callbacks they invoke with the recorded
+// ones, to reproduce failed joins reported by users.
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/godave"
+)
+
+// EventType is the direction of an Event.
+type EventType string
+
+const (
+	// EventTypeCall is a call of the voice gateway into the session.
+	EventTypeCall EventType = "call"
+	// EventTypeCallback is a godave.Callbacks invocation of the session.
+	EventTypeCallback EventType = "callback"
+)
+
+// Method names which are not methods of godave.Session or godave.Callbacks.
+const (
+	// MethodNewSession is the creation of a session, the UserID of its Event is the user of the session.
+	MethodNewSession = "NewSession"
+)
+
+// Event is a recorded call or callback of a session. Only the arguments of the Method are set.
+type Event struct {
+	// Session identifies the session within the transcript, in the order sessions were created.
+	Session int       `json:"session"`
+	Time    time.Time `json:"time"`
+	Type    EventType `json:"type"`
+	// Method is the name of the godave.Session or godave.Callbacks method, or MethodNewSession.
+	Method          string           `json:"method"`
+	UserID          godave.UserID    `json:"user_id,omitempty"`
+	ChannelID       godave.ChannelID `json:"channel_id,omitempty"`
+	TransitionID    *uint16          `json:"transition_id,omitempty"`
+	ProtocolVersion *uint16          `json:"protocol_version,omitempty"`
+	Epoch           *int             `json:"epoch,omitempty"`
+	Payload         []byte           `json:"payload,omitempty"`
+	// Error is the error returned by a callback.
+	Error string `json:"error,omitempty"`
+}
+
+// Writer writes events to a JSONL transcript. It is safe for concurrent use.
+type Writer struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	clock   func() time.Time
+	session int
+}
+
+// NewWriter returns a Writer writing events to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		encoder: json.NewEncoder(w),
+		clock:   time.Now,
+	}
+}
+
+// nextSession returns the identifier of the next session recorded to the transcript.
+func (w *Writer) nextSession() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.session++
+	return w.session
+}
+
+// Write writes the event to the transcript, setting its Time if it is zero.
+func (w *Writer) Write(event Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if event.Time.IsZero() {
+		event.Time = w.clock()
+	}
+	return w.encoder.Encode(event)
+}
+
+// Read reads all events of a transcript.
+func Read(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	// payloads of large groups exceed the default token size
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,81 @@
+package transcript
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/disgoorg/godave"
+)
+
+// fakeSession answers the voice gateway like a DAVE session would, rejecting welcomes equal to rejected.
+type fakeSession struct {
+	godave.Session
+	callbacks godave.Callbacks
+	rejected  string
+}
+
+func (s *fakeSession) Close() error {
+	return nil
+}
+
+func (s *fakeSession) OnSelectProtocolAck(uint16) {
+	_ = s.callbacks.SendMLSKeyPackage([]byte("key package"))
+}
+
+func (s *fakeSession) OnDaveMLSWelcome(transitionID uint16, welcomeMessage []byte) {
+	if string(welcomeMessage) == s.rejected {
+		_ = s.callbacks.SendInvalidCommitWelcome(transitionID)
+		return
+	}
+	_ = s.callbacks.SendReadyForTransition(transitionID)
+}
+
+func newFakeSessionCreateFunc(rejected string) godave.SessionCreateFunc {
+	return func(_ *slog.Logger, _ godave.UserID, callbacks godave.Callbacks) godave.Session {
+		return &fakeSession{callbacks: callbacks, rejected: rejected}
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	var buf bytes.Buffer
+	create := NewSessionCreateFunc(newFakeSessionCreateFunc("bad welcome"), NewWriter(&buf))
+
+	session := create(slog.New(slog.DiscardHandler), "1", &collectingCallbacks{})
+	session.OnSelectProtocolAck(1)
+	session.OnDaveMLSWelcome(3, []byte("bad welcome"))
+	_ = session.Close()
+
+	events, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var methods []string
+	for _, event := range events {
+		methods = append(methods, event.Method)
+	}
+	if got, want := strings.Join(methods, " "), "NewSession OnSelectProtocolAck SendMLSKeyPackage OnDaveMLSWelcome SendInvalidCommitWelcome Close"; got != want {
+		t.Fatalf("expected events %s, got %s", want, got)
+	}
+	if events[0].UserID != "1" || *events[4].TransitionID != 3 {
+		t.Errorf("expected recorded arguments, got %+v", events)
+	}
+
+	diffs, err := Replay(newFakeSessionCreateFunc("bad welcome"), slog.New(slog.DiscardHandler), events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("expected identical replay, got %v", diffs)
+	}
+
+	diffs, err = Replay(newFakeSessionCreateFunc(""), slog.New(slog.DiscardHandler), events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "session 1 OnDaveMLSWelcome(transition 3, 11 bytes): expected [SendInvalidCommitWelcome(transition 3)], got [SendReadyForTransition(transition 3)]"
+	if len(diffs) != 1 || diffs[0].String() != want {
+		t.Errorf("expected diff %s, got %v", want, diffs)
+	}
+}